@@ -0,0 +1,189 @@
+package graph
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+)
+
+var (
+	ErrGraphMismatch = fmt.Errorf("saved preprocessing does not match the graph")
+)
+
+// Landmarks is the preprocessing for ALT (AStar, Landmarks and the Triangle inequality).
+// The shortest distances to and from a few landmark vertices are stored for every vertex,
+// and the triangle inequality then gives a lower bound on the distance between any two
+// vertices, which makes a far better AStar heuristic than straight line distance on
+// graphs like road networks.
+//
+// Vertices are stored by their position in graph.Vertices(), so the graph must return
+// its vertices in the same order every time for saved landmarks to be loaded again.
+type Landmarks struct {
+	graph     WeightedDigraph
+	index     map[Vertex]int
+	landmarks []int
+	from      [][]float32 // from[l][v] is the distance from landmark l to v
+	to        [][]float32 // to[l][v] is the distance from v to landmark l
+}
+
+// NewLandmarks picks count landmarks spread out over the graph and builds their
+// distance tables. The first landmark is the vertex furthest from a random start,
+// and each one after is the vertex furthest from all the landmarks picked so far
+func NewLandmarks(graph WeightedDigraph, count int, seed int64) *Landmarks {
+	verts := graph.Vertices()
+	l := Landmarks{
+		graph: graph,
+		index: vertexIndex(verts),
+	}
+	if count <= 0 || len(verts) == 0 {
+		return &l
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	start := distanceTable(verts, Dijkstra(graph, verts[rng.Intn(len(verts))]))
+	next := furthest(start, nil)
+
+	reversed := newReversedDigraph(graph)
+	nearest := make([]float32, len(verts))
+	for i := range nearest {
+		nearest[i] = float32Inf
+	}
+	for len(l.landmarks) < count && next >= 0 {
+		l.landmarks = append(l.landmarks, next)
+		from := distanceTable(verts, Dijkstra(graph, verts[next]))
+		l.from = append(l.from, from)
+		l.to = append(l.to, distanceTable(verts, Dijkstra(reversed, verts[next])))
+
+		for i, d := range from {
+			if d < nearest[i] {
+				nearest[i] = d
+			}
+		}
+		next = furthest(nearest, l.landmarks)
+	}
+
+	return &l
+}
+
+func vertexIndex(verts []Vertex) map[Vertex]int {
+	index := make(map[Vertex]int, len(verts))
+	for i, v := range verts {
+		index[v] = i
+	}
+	return index
+}
+
+func distanceTable(verts []Vertex, attrs RelaxableAttributes) []float32 {
+	table := make([]float32, len(verts))
+	for i, v := range verts {
+		table[i] = attrs[v].ShortestEstimateFromSource()
+	}
+	return table
+}
+
+// furthest returns the index with the largest finite distance that isn't excluded
+func furthest(dist []float32, exclude []int) int {
+	best := -1
+	for i, d := range dist {
+		if math.IsInf(float64(d), 1) || containsInt(exclude, i) {
+			continue
+		}
+		if best < 0 || d > dist[best] {
+			best = i
+		}
+	}
+	return best
+}
+
+func containsInt(is []int, i int) bool {
+	for _, j := range is {
+		if i == j {
+			return true
+		}
+	}
+	return false
+}
+
+// Landmarks returns the landmark vertices
+func (l *Landmarks) Landmarks() []Vertex {
+	verts := l.graph.Vertices()
+	out := make([]Vertex, len(l.landmarks))
+	for i, li := range l.landmarks {
+		out[i] = verts[li]
+	}
+	return out
+}
+
+// Heuristic is the ALT lower bound on the distance from one vertex to another, it can
+// be passed to AStarHeuristic
+func (l *Landmarks) Heuristic(from, to Vertex) float32 {
+	fi, fok := l.index[from]
+	ti, tok := l.index[to]
+	if !fok || !tok {
+		return 0
+	}
+
+	var best float32
+	for i := range l.landmarks {
+		// d(l, to) <= d(l, from) + d(from, to)
+		if d := l.from[i][ti] - l.from[i][fi]; d > best && !isInf32(l.from[i][ti]) && !isInf32(l.from[i][fi]) {
+			best = d
+		}
+		// d(from, l) <= d(from, to) + d(to, l)
+		if d := l.to[i][fi] - l.to[i][ti]; d > best && !isInf32(l.to[i][fi]) && !isInf32(l.to[i][ti]) {
+			best = d
+		}
+	}
+	return best
+}
+
+// Path finds the shortest path from source to destination with AStar, using the landmarks
+// as the heuristic
+func (l *Landmarks) Path(source, destination Vertex) (Path, bool) {
+	attrs := AStarHeuristic(l.graph, source, destination, l.Heuristic)
+	return PathFromAttributes(l.graph, attrs.ToRelaxableAttributes(), source, destination)
+}
+
+func isInf32(f float32) bool {
+	return math.IsInf(float64(f), 0)
+}
+
+type landmarkData struct {
+	Vertices  int
+	Landmarks []int
+	From      [][]float32
+	To        [][]float32
+}
+
+// Save writes the landmark tables to w so they can be loaded with LoadLandmarks
+func (l *Landmarks) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(landmarkData{
+		Vertices:  len(l.index),
+		Landmarks: l.landmarks,
+		From:      l.from,
+		To:        l.to,
+	})
+}
+
+// LoadLandmarks reads landmark tables written by Save, for use with the same graph
+func LoadLandmarks(r io.Reader, graph WeightedDigraph) (*Landmarks, error) {
+	var data landmarkData
+	if err := gob.NewDecoder(r).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	verts := graph.Vertices()
+	if data.Vertices != len(verts) {
+		return nil, ErrGraphMismatch
+	}
+
+	return &Landmarks{
+		graph:     graph,
+		index:     vertexIndex(verts),
+		landmarks: data.Landmarks,
+		from:      data.From,
+		to:        data.To,
+	}, nil
+}
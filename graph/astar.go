@@ -1,7 +1,6 @@
 package graph
 
 import (
-	"container/heap"
 	"math"
 )
 
@@ -48,14 +47,60 @@ func (aa AStarAttributes) ToAttributeMap() AttributeMap {
 	return out
 }
 
-func initAStarSingleSource(wg WeightedDigraph, source, destination EstimatedVertex) AStarAttributes {
-	at := make(AStarAttributes)
+func (aa AStarAttributes) ToRelaxableAttributes() RelaxableAttributes {
+	out := make(RelaxableAttributes)
+	for v, a := range aa {
+		out[v] = a
+	}
+	return out
+}
+
+// HeuristicAttributes are the attributes found by AStarHeuristic, keyed by any vertex
+// as the vertices needn't be EstimatedVertex
+type HeuristicAttributes map[Vertex]*AStarAttribute
+
+func (ha HeuristicAttributes) ToAttributeMap() AttributeMap {
+	out := make(AttributeMap)
+	for v, a := range ha {
+		out[v] = a
+	}
+	return out
+}
+
+func (ha HeuristicAttributes) ToRelaxableAttributes() RelaxableAttributes {
+	out := make(RelaxableAttributes)
+	for v, a := range ha {
+		out[v] = a
+	}
+	return out
+}
+
+// estimated rekeys the attributes by EstimatedVertex, every vertex must be one
+func (ha HeuristicAttributes) estimated() AStarAttributes {
+	out := make(AStarAttributes, len(ha))
+	for v, a := range ha {
+		out[v.(EstimatedVertex)] = a
+	}
+	return out
+}
+
+// Heuristic estimates the cost of the shortest path between two vertices, for AStar
+// to find the shortest path it must never overestimate
+type Heuristic func(from, to Vertex) float32
+
+// EstimatedVertexHeuristic is the heuristic used by AStar, it uses the vertices' own estimates
+func EstimatedVertexHeuristic(from, to Vertex) float32 {
+	return from.(EstimatedVertex).EstimatedDistance(to)
+}
+
+func initAStarSingleSource(wg WeightedDigraph, source, destination Vertex, h Heuristic) HeuristicAttributes {
+	at := make(HeuristicAttributes)
 	for _, v := range wg.Vertices() {
-		at[v.(EstimatedVertex)] = &AStarAttribute{
+		at[v] = &AStarAttribute{
 			DijkstraAttribute: &DijkstraAttribute{
 				ShortestEstimate: float32(math.Inf(1)),
 			},
-			estDest: float32(v.(EstimatedVertex).EstimatedDistance(destination)),
+			estDest: h(v, destination),
 		}
 	}
 
@@ -65,6 +110,24 @@ func initAStarSingleSource(wg WeightedDigraph, source, destination EstimatedVert
 }
 
 func AStar(wg WeightedDigraph, source, destination EstimatedVertex) AStarAttributes {
+	return aStar(wg, source, destination, EstimatedVertexHeuristic, nil).estimated()
+}
+
+// AStarHeuristic is AStar using the given heuristic rather than the vertices' own estimates,
+// so the vertices don't need to be EstimatedVertex
+func AStarHeuristic(wg WeightedDigraph, source, destination Vertex, h Heuristic) HeuristicAttributes {
+	return aStar(wg, source, destination, h, nil)
+}
+
+// AStarLimited is AStar which stops early if it hits any of the given limits, returning
+// the attributes of the vertices expanded so far and a *SearchStoppedError
+func AStarLimited(wg WeightedDigraph, source, destination EstimatedVertex, opts ...SearchOption) (AStarAttributes, error) {
+	limits := newSearchLimits(opts)
+	attrs := aStar(wg, source, destination, EstimatedVertexHeuristic, limits)
+	return attrs.estimated(), limits.err
+}
+
+func aStar(wg WeightedDigraph, source, destination Vertex, h Heuristic, limits *searchLimits) HeuristicAttributes {
 	// A Star basically is a mix of dijkstra and BFS.
 	// From BFS we use the concept of an expanding frontier of cells
 	// that neighbour the source, rather than using the dijkstra style
@@ -76,37 +139,13 @@ func AStar(wg WeightedDigraph, source, destination EstimatedVertex) AStarAttribu
 	// if we then used this with Dijkstra's algorithm, we would start far from the source,
 	// and the algorithm would have to path backwards to the source
 
-	attrs := initAStarSingleSource(wg, source, destination)
-	relaxableAttrs := make(RelaxableAttributes)
-	for v, a := range attrs {
-		relaxableAttrs[v] = a
-	}
-	// This is the only changed bit, we need to change all the shortest estimates
-	// to mirror the estimatedVertex estimates
-
-	outs := make(AStarAttributes)
-	queue := make(MinPriorityQueue, 0)
-	queue = append(queue, NewVertexPriorityItem(
-		source,
-		&attrs[source].DijkstraAttribute.ShortestEstimate,
-	))
-	heap.Init(&queue)
-
-	for queue.Len() > 0 {
-		current := heap.Pop(&queue).(*VertexPriorityItem).Vertex().(EstimatedVertex)
-		if current == destination {
+	state := newAStarState(wg, source, destination, h)
+	for !state.Done() {
+		if limits.stop(state.attrs[state.Next()].TotalCostEstimate()) {
 			break
 		}
-
-		for _, edge := range wg.Edges()[current] {
-			if Relax(wg, edge, relaxableAttrs) {
-				queue.Push(NewVertexPriorityItem(
-					edge.To(),
-					&attrs[edge.To().(EstimatedVertex)].DijkstraAttribute.ShortestEstimate,
-				))
-			}
-		}
+		state.Step()
 	}
 
-	return outs
+	return state.outs
 }
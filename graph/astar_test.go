@@ -0,0 +1,44 @@
+package graph
+
+import (
+	"testing"
+)
+
+// lineCell is a cell of a one dimensional grid, estimating distance by how far apart
+// two cells are
+type lineCell int
+
+func (c lineCell) EstimatedDistance(to Vertex) float32 {
+	d := float32(c - to.(lineCell))
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func TestAStar(t *testing.T) {
+	verts := make([]Vertex, 10)
+	for i := range verts {
+		verts[i] = lineCell(i)
+	}
+	g := newTestGraph(verts...)
+	for i := 0; i < 9; i++ {
+		g.addUndirectedEdge(lineCell(i), lineCell(i+1), 1)
+	}
+
+	attrs := AStar(g, lineCell(5), lineCell(9))
+	dest, ok := attrs[lineCell(9)]
+	if !ok {
+		t.Fatal("destination not among the expanded vertices")
+	}
+	if cost := dest.ShortestEstimateFromSource(); cost != 4 {
+		t.Errorf("destination reached at cost %f, expected 4", cost)
+	}
+	// the cells behind the source lead away from the destination, so ordering the
+	// search by estimated total cost never expands them
+	for i := 0; i < 5; i++ {
+		if _, ok := attrs[lineCell(i)]; ok {
+			t.Errorf("cell %d expanded, though it leads away from the destination", i)
+		}
+	}
+}
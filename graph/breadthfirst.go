@@ -1,7 +1,6 @@
 package graph
 
 import (
-	"container/list"
 	"fmt"
 )
 
@@ -75,37 +74,35 @@ func initBFSTree(graph DirectedGraph, source Vertex) BreadthFirstTree {
 	return bfsTree
 }
 
-func breadthFirstSearch(graph DirectedGraph, source Vertex, callback BFSCallback) BreadthFirstTree {
-	bfsTree := initBFSTree(graph, source)
-	queue := list.New() // We are using a linked list as the queue, where PushBack is used as Enqueue and Remove is Dequeue
-	queue.PushBack(source)
-
-	for queue.Len() > 0 {
-		fromVertex := queue.Front().Value.(Vertex)
-		queue.Remove(queue.Front())                      // remove u from the list
-		for _, edge := range graph.Edges()[fromVertex] { //Loop over all the edges of u
-			toVertex := edge.To()
-			if bfsTree[toVertex].Color == BFSWhite { //if we haven't visited this vert before
-				bfsTree[toVertex].Color = BFSGray                             //make it so we have
-				bfsTree[toVertex].distance = bfsTree[fromVertex].distance + 1 //set its distance
-				bfsTree[toVertex].predecessor = fromVertex
-				queue.PushBack(toVertex)
-			}
+func breadthFirstSearch(graph DirectedGraph, source Vertex, callback BFSCallback, limits *searchLimits) BreadthFirstTree {
+	state := NewBFSState(graph, source)
+	for !state.Done() {
+		if limits.stop(float32(state.tree[state.Next()].distance)) {
+			break
 		}
-		bfsTree[fromVertex].Color = BFSBlack
+		state.Step()
 
 		if callback != nil {
-			callback(fromVertex, bfsTree)
+			callback(state.Current(), state.tree)
 		}
 	}
 
-	return bfsTree
+	return state.tree
 }
 
 func BreadthFirstSearch(graph DirectedGraph, source Vertex) BreadthFirstTree {
-	return breadthFirstSearch(graph, source, nil)
+	return breadthFirstSearch(graph, source, nil, nil)
 }
 
 func BreadthFirstSearchCallback(graph DirectedGraph, source Vertex, cb BFSCallback) BreadthFirstTree {
-	return breadthFirstSearch(graph, source, cb)
+	return breadthFirstSearch(graph, source, cb, nil)
+}
+
+// BreadthFirstSearchLimited is BreadthFirstSearch which stops early if it hits any of
+// the given limits, returning the partial tree and a *SearchStoppedError. Vertices
+// still gray in a partial tree were reached but not expanded
+func BreadthFirstSearchLimited(graph DirectedGraph, source Vertex, opts ...SearchOption) (BreadthFirstTree, error) {
+	limits := newSearchLimits(opts)
+	tree := breadthFirstSearch(graph, source, nil, limits)
+	return tree, limits.err
 }
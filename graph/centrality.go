@@ -0,0 +1,112 @@
+package graph
+
+// Betweenness returns, for every vertex, the number of shortest paths between other
+// pairs of vertices that pass through it, counted in hops. Where a pair has several
+// shortest paths each counts for its share. This uses Brandes' algorithm
+func Betweenness(graph DirectedGraph) map[Vertex]float64 {
+	verts := graph.Vertices()
+	vertex, _ := brandes(simpleAdjacency(graph, verts))
+	out := make(map[Vertex]float64, len(verts))
+	for i, v := range verts {
+		out[v] = vertex[i]
+	}
+	return out
+}
+
+// EdgeBetweenness returns, for every edge, the number of shortest paths between pairs
+// of vertices that use it, counted in hops. Parallel edges are treated as one and share
+// its betweenness equally
+func EdgeBetweenness(graph DirectedGraph) map[Edge]float64 {
+	verts := graph.Vertices()
+	index := vertexIndex(verts)
+	_, pairs := brandes(simpleAdjacency(graph, verts))
+
+	parallel := make(map[[2]int]int)
+	for v, es := range graph.Edges() {
+		for _, e := range es {
+			parallel[[2]int{index[v], index[e.To()]}]++
+		}
+	}
+	out := make(map[Edge]float64)
+	for v, es := range graph.Edges() {
+		for _, e := range es {
+			pair := [2]int{index[v], index[e.To()]}
+			out[e] = pairs[pair] / float64(parallel[pair])
+		}
+	}
+	return out
+}
+
+// simpleAdjacency numbers the vertices in the order of verts and lists the distinct
+// successors of each, dropping self loops
+func simpleAdjacency(graph DirectedGraph, verts []Vertex) [][]int {
+	index := vertexIndex(verts)
+	adj := make([][]int, len(verts))
+	for i, v := range verts {
+		seen := make(map[int]bool)
+		for _, e := range graph.Edges()[v] {
+			j := index[e.To()]
+			if j != i && !seen[j] {
+				seen[j] = true
+				adj[i] = append(adj[i], j)
+			}
+		}
+	}
+	return adj
+}
+
+// brandes finds the betweenness of every vertex and every edge, given as a pair of
+// vertex numbers, of an unweighted graph with adjacency adj
+func brandes(adj [][]int) (vertex []float64, edge map[[2]int]float64) {
+	n := len(adj)
+	vertex = make([]float64, n)
+	edge = make(map[[2]int]float64)
+
+	sigma := make([]float64, n) // the number of shortest paths from s
+	dist := make([]int, n)
+	delta := make([]float64, n)
+	preds := make([][]int, n)
+	for s := 0; s < n; s++ {
+		for i := range dist {
+			sigma[i] = 0
+			dist[i] = -1
+			delta[i] = 0
+			preds[i] = preds[i][:0]
+		}
+		sigma[s] = 1
+		dist[s] = 0
+
+		order := make([]int, 0, n) // vertices in the order they were reached
+		queue := []int{s}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			order = append(order, v)
+			for _, w := range adj[v] {
+				if dist[w] < 0 {
+					dist[w] = dist[v] + 1
+					queue = append(queue, w)
+				}
+				if dist[w] == dist[v]+1 {
+					sigma[w] += sigma[v]
+					preds[w] = append(preds[w], v)
+				}
+			}
+		}
+
+		// work back from the furthest vertices, passing each one's
+		// paths back to its predecessors in proportion
+		for i := len(order) - 1; i >= 0; i-- {
+			w := order[i]
+			for _, v := range preds[w] {
+				c := sigma[v] / sigma[w] * (1 + delta[w])
+				edge[[2]int{v, w}] += c
+				delta[v] += c
+			}
+			if w != s {
+				vertex[w] += delta[w]
+			}
+		}
+	}
+	return vertex, edge
+}
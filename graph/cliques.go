@@ -0,0 +1,261 @@
+package graph
+
+// adjacencyBitsets numbers the vertices in the order of verts and gives each a bitset
+// of its undirected neighbours. If complement is true it's the neighbours of the
+// complement graph instead, every other vertex that isn't a neighbour
+func adjacencyBitsets(verts []Vertex, neighbors map[Vertex][]Vertex, complement bool) []bitset {
+	index := vertexIndex(verts)
+	adj := make([]bitset, len(verts))
+	for i, v := range verts {
+		adj[i] = newBitset(len(verts))
+		for _, n := range neighbors[v] {
+			adj[i].set(index[n])
+		}
+		if complement {
+			for j := range verts {
+				if j == i {
+					continue
+				}
+				if adj[i].has(j) {
+					adj[i].clear(j)
+				} else {
+					adj[i].set(j)
+				}
+			}
+		}
+	}
+	return adj
+}
+
+func (b bitset) clear(i int) {
+	b[i/64] &^= 1 << uint(i%64)
+}
+
+// intersect returns the members of verts that are in b
+func (b bitset) intersect(verts []int) []int {
+	out := make([]int, 0, len(verts))
+	for _, v := range verts {
+		if b.has(v) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// MaximalCliques lists every maximal clique of the graph, treated as undirected, using
+// Bron-Kerbosch with pivoting. The outer level visits the vertices in a degeneracy
+// ordering, so each search only starts with the few neighbours later in the order,
+// which keeps sparse graphs fast. Isolated vertices are cliques of their own
+func MaximalCliques(graph DirectedGraph) [][]Vertex {
+	verts := graph.Vertices()
+	neighbors := undirectedNeighbors(graph)
+	adj := adjacencyBitsets(verts, neighbors, false)
+	index := vertexIndex(verts)
+
+	// smallest last visits the vertices in the reverse of a degeneracy ordering
+	order := SmallestLast(verts, neighbors)
+	position := make([]int, len(verts))
+	for i, v := range order {
+		position[index[v]] = len(order) - 1 - i
+	}
+
+	cliques := make([][]Vertex, 0)
+	report := func(r []int) {
+		clique := make([]Vertex, len(r))
+		for i, v := range r {
+			clique[i] = verts[v]
+		}
+		cliques = append(cliques, clique)
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		v := index[order[i]]
+		p := make([]int, 0)
+		x := make([]int, 0)
+		for _, n := range neighbors[order[i]] {
+			if position[index[n]] > position[v] {
+				p = append(p, index[n])
+			} else {
+				x = append(x, index[n])
+			}
+		}
+		bronKerbosch(adj, []int{v}, p, x, report)
+	}
+	return cliques
+}
+
+// bronKerbosch reports every maximal clique that contains all of r, some of p and none of x
+func bronKerbosch(adj []bitset, r, p, x []int, report func([]int)) {
+	if len(p) == 0 {
+		if len(x) == 0 {
+			report(r)
+		}
+		return
+	}
+
+	// the pivot is the vertex with the most neighbours in p, any maximal clique
+	// must include it or one of its non-neighbours, so only those are branched on
+	pivot, most := -1, -1
+	for _, set := range [2][]int{p, x} {
+		for _, u := range set {
+			if n := len(adj[u].intersect(p)); n > most {
+				pivot, most = u, n
+			}
+		}
+	}
+
+	branches := make([]int, 0, len(p))
+	for _, v := range p {
+		if !adj[pivot].has(v) {
+			branches = append(branches, v)
+		}
+	}
+	for _, v := range branches {
+		rv := append(r[:len(r):len(r)], v)
+		bronKerbosch(adj, rv, adj[v].intersect(p), adj[v].intersect(x), report)
+
+		// v is done with, so move it from p to x
+		for i, u := range p {
+			if u == v {
+				p = append(p[:i:i], p[i+1:]...)
+				break
+			}
+		}
+		x = append(x[:len(x):len(x)], v)
+	}
+}
+
+// MaximumClique finds a largest clique of the graph, treated as undirected, by branch
+// and bound, using a greedy coloring of the candidates to bound how big a clique they
+// could still make. This is exponential in the worst case, so is meant for small graphs
+func MaximumClique(graph DirectedGraph) []Vertex {
+	verts := graph.Vertices()
+	neighbors := undirectedNeighbors(graph)
+	return largestClique(verts, adjacencyBitsets(verts, neighbors, false), LargestFirst(verts, neighbors))
+}
+
+// MaximumIndependentSet finds a largest set of vertices of the graph, treated as
+// undirected, no two of which are neighbours. It is a maximum clique of the complement
+// graph, so is exponential in the worst case and meant for small graphs
+func MaximumIndependentSet(graph DirectedGraph) []Vertex {
+	verts := graph.Vertices()
+	neighbors := undirectedNeighbors(graph)
+	order := make([]Vertex, len(verts))
+	for i, v := range LargestFirst(verts, neighbors) {
+		// the fewest neighbours in the graph is the most in its complement
+		order[len(verts)-1-i] = v
+	}
+	return largestClique(verts, adjacencyBitsets(verts, neighbors, true), order)
+}
+
+// GreedyIndependentSet builds an independent set of the graph, treated as undirected, by
+// repeatedly taking the vertex with the fewest remaining neighbours and removing it and
+// its neighbours. The set is maximal, nothing more can be added, but may not be maximum
+func GreedyIndependentSet(graph DirectedGraph) []Vertex {
+	verts := graph.Vertices()
+	neighbors := undirectedNeighbors(graph)
+	degree := make(map[Vertex]int, len(verts))
+	for _, v := range verts {
+		degree[v] = len(neighbors[v])
+	}
+
+	removed := make(map[Vertex]bool, len(verts))
+	remove := func(v Vertex) {
+		removed[v] = true
+		for _, n := range neighbors[v] {
+			degree[n]--
+		}
+	}
+	set := make([]Vertex, 0)
+	for len(removed) < len(verts) {
+		var smallest Vertex
+		for _, v := range verts {
+			if !removed[v] && (smallest == nil || degree[v] < degree[smallest]) {
+				smallest = v
+			}
+		}
+		set = append(set, smallest)
+		remove(smallest)
+		for _, n := range neighbors[smallest] {
+			if !removed[n] {
+				remove(n)
+			}
+		}
+	}
+	return set
+}
+
+// largestClique is the branch and bound behind MaximumClique, searching the candidates
+// in the given starting order
+func largestClique(verts []Vertex, adj []bitset, order []Vertex) []Vertex {
+	index := vertexIndex(verts)
+	p := make([]int, len(order))
+	for i, v := range order {
+		p[i] = index[v]
+	}
+
+	best := make([]int, 0)
+	var expand func(r, p []int)
+	expand = func(r, p []int) {
+		sorted, colors := colorSort(adj, p)
+		for i := len(sorted) - 1; i >= 0; i-- {
+			// p[:i+1] can be split into colors[i] independent sets, so
+			// at most that many of them can be added to the clique
+			if len(r)+colors[i] <= len(best) {
+				return
+			}
+			v := sorted[i]
+			rv := append(r[:len(r):len(r)], v)
+			next := adj[v].intersect(sorted[:i])
+			if len(next) == 0 {
+				if len(rv) > len(best) {
+					best = rv
+				}
+				continue
+			}
+			expand(rv, next)
+		}
+	}
+	expand(make([]int, 0), p)
+
+	clique := make([]Vertex, len(best))
+	for i, v := range best {
+		clique[i] = verts[v]
+	}
+	return clique
+}
+
+// colorSort greedily colors the candidates, keeping their order within each color, and
+// returns them sorted by color along with the number of colors used up to each one
+func colorSort(adj []bitset, p []int) (sorted, colors []int) {
+	classes := make([][]int, 0)
+	for _, v := range p {
+		placed := false
+		for c, class := range classes {
+			free := true
+			for _, u := range class {
+				if adj[v].has(u) {
+					free = false
+					break
+				}
+			}
+			if free {
+				classes[c] = append(class, v)
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			classes = append(classes, []int{v})
+		}
+	}
+
+	sorted = make([]int, 0, len(p))
+	colors = make([]int, 0, len(p))
+	for c, class := range classes {
+		for _, v := range class {
+			sorted = append(sorted, v)
+			colors = append(colors, c+1)
+		}
+	}
+	return sorted, colors
+}
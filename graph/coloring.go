@@ -0,0 +1,385 @@
+package graph
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+)
+
+var (
+	ErrNotBipartite = fmt.Errorf("graph is not bipartite")
+)
+
+// undirectedNeighbors treats a directed graph as undirected, returning the distinct
+// neighbours of every vertex whichever way the edges between them point. Self loops
+// are dropped, and neighbours are in the order of graph.Vertices()
+func undirectedNeighbors(graph DirectedGraph) map[Vertex][]Vertex {
+	verts := graph.Vertices()
+	index := vertexIndex(verts)
+	adjacent := make(map[Vertex]map[Vertex]bool, len(verts))
+	for _, v := range verts {
+		adjacent[v] = make(map[Vertex]bool)
+	}
+	for v, es := range graph.Edges() {
+		for _, e := range es {
+			if e.To() != v {
+				adjacent[v][e.To()] = true
+				adjacent[e.To()][v] = true
+			}
+		}
+	}
+
+	neighbors := make(map[Vertex][]Vertex, len(verts))
+	for _, v := range verts {
+		ns := make([]Vertex, 0, len(adjacent[v]))
+		for n := range adjacent[v] {
+			ns = append(ns, n)
+		}
+		sort.Slice(ns, func(i, j int) bool {
+			return index[ns[i]] < index[ns[j]]
+		})
+		neighbors[v] = ns
+	}
+	return neighbors
+}
+
+// Coloring assigns each vertex a color, numbered from 0, so that no two
+// neighbouring vertices share a color
+type Coloring map[Vertex]int
+
+// Colors returns the number of colors used
+func (c Coloring) Colors() int {
+	n := 0
+	for _, color := range c {
+		if color+1 > n {
+			n = color + 1
+		}
+	}
+	return n
+}
+
+// Classes returns the vertices of each color
+func (c Coloring) Classes() [][]Vertex {
+	classes := make([][]Vertex, c.Colors())
+	for v, color := range c {
+		classes[color] = append(classes[color], v)
+	}
+	return classes
+}
+
+// VertexOrdering decides the order a greedy coloring visits the vertices of a graph in,
+// given the graph's undirected neighbours
+type VertexOrdering func(verts []Vertex, neighbors map[Vertex][]Vertex) []Vertex
+
+// NaturalOrder visits vertices in the order of graph.Vertices()
+func NaturalOrder(verts []Vertex, neighbors map[Vertex][]Vertex) []Vertex {
+	return verts
+}
+
+// LargestFirst visits vertices with the most neighbours first
+func LargestFirst(verts []Vertex, neighbors map[Vertex][]Vertex) []Vertex {
+	order := make([]Vertex, len(verts))
+	copy(order, verts)
+	sort.SliceStable(order, func(i, j int) bool {
+		return len(neighbors[order[i]]) > len(neighbors[order[j]])
+	})
+	return order
+}
+
+// SmallestLast repeatedly removes the vertex with the fewest remaining neighbours, and
+// visits them in the reverse of the order they were removed
+func SmallestLast(verts []Vertex, neighbors map[Vertex][]Vertex) []Vertex {
+	degree := make(map[Vertex]int, len(verts))
+	for _, v := range verts {
+		degree[v] = len(neighbors[v])
+	}
+	removed := make(map[Vertex]bool, len(verts))
+	order := make([]Vertex, len(verts))
+	for i := len(verts) - 1; i >= 0; i-- {
+		var smallest Vertex
+		for _, v := range verts {
+			if !removed[v] && (smallest == nil || degree[v] < degree[smallest]) {
+				smallest = v
+			}
+		}
+		removed[smallest] = true
+		order[i] = smallest
+		for _, n := range neighbors[smallest] {
+			degree[n]--
+		}
+	}
+	return order
+}
+
+// RandomOrder returns an ordering that shuffles the vertices using the given seed
+func RandomOrder(seed int64) VertexOrdering {
+	return func(verts []Vertex, neighbors map[Vertex][]Vertex) []Vertex {
+		order := make([]Vertex, len(verts))
+		copy(order, verts)
+		rand.New(rand.NewSource(seed)).Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+		return order
+	}
+}
+
+// lowestFreeColor returns the lowest color not used by any colored neighbour of v
+func lowestFreeColor(v Vertex, neighbors map[Vertex][]Vertex, coloring Coloring) int {
+	used := make(map[int]bool)
+	for _, n := range neighbors[v] {
+		if c, ok := coloring[n]; ok {
+			used[c] = true
+		}
+	}
+	color := 0
+	for used[color] {
+		color++
+	}
+	return color
+}
+
+// GreedyColoring colors the graph, treated as undirected, by giving each vertex in turn
+// the lowest color none of its neighbours have. The number of colors used depends
+// heavily on the order
+func GreedyColoring(graph DirectedGraph, order VertexOrdering) Coloring {
+	neighbors := undirectedNeighbors(graph)
+	coloring := make(Coloring)
+	for _, v := range order(graph.Vertices(), neighbors) {
+		coloring[v] = lowestFreeColor(v, neighbors, coloring)
+	}
+	return coloring
+}
+
+// WelshPowell colors the graph, treated as undirected, one color at a time. Vertices
+// are sorted by degree, and each pass gives the current color to every remaining
+// vertex that has no neighbour with that color yet
+func WelshPowell(graph DirectedGraph) Coloring {
+	neighbors := undirectedNeighbors(graph)
+	order := LargestFirst(graph.Vertices(), neighbors)
+	coloring := make(Coloring)
+	for color := 0; len(coloring) < len(order); color++ {
+		for _, v := range order {
+			if _, ok := coloring[v]; ok {
+				continue
+			}
+			free := true
+			for _, n := range neighbors[v] {
+				if c, ok := coloring[n]; ok && c == color {
+					free = false
+					break
+				}
+			}
+			if free {
+				coloring[v] = color
+			}
+		}
+	}
+	return coloring
+}
+
+// DSatur colors the graph, treated as undirected, always coloring next the vertex whose
+// neighbours already use the most different colors (its saturation), breaking ties by
+// the most uncolored neighbours
+func DSatur(graph DirectedGraph) Coloring {
+	neighbors := undirectedNeighbors(graph)
+	coloring := make(Coloring)
+	for len(coloring) < len(neighbors) {
+		v := mostSaturated(graph.Vertices(), neighbors, coloring)
+		coloring[v] = lowestFreeColor(v, neighbors, coloring)
+	}
+	return coloring
+}
+
+// saturation returns the number of distinct colors and the number of uncolored vertices
+// among v's neighbours
+func saturation(v Vertex, neighbors map[Vertex][]Vertex, coloring Coloring) (colors, uncolored int) {
+	seen := make(map[int]bool)
+	for _, n := range neighbors[v] {
+		if c, ok := coloring[n]; ok {
+			seen[c] = true
+		} else {
+			uncolored++
+		}
+	}
+	return len(seen), uncolored
+}
+
+func mostSaturated(verts []Vertex, neighbors map[Vertex][]Vertex, coloring Coloring) Vertex {
+	var best Vertex
+	bestSat, bestDeg := -1, -1
+	for _, v := range verts {
+		if _, ok := coloring[v]; ok {
+			continue
+		}
+		sat, deg := saturation(v, neighbors, coloring)
+		if sat > bestSat || (sat == bestSat && deg > bestDeg) {
+			best, bestSat, bestDeg = v, sat, deg
+		}
+	}
+	return best
+}
+
+// ChromaticNumber finds the fewest colors the graph, treated as undirected, can be
+// colored with, along with a coloring that uses them. It is a branch and bound search
+// seeded with the DSatur coloring, so its running time is exponential and it is only
+// suitable for small graphs, up to a few dozen vertices
+func ChromaticNumber(graph DirectedGraph) (int, Coloring) {
+	neighbors := undirectedNeighbors(graph)
+	verts := graph.Vertices()
+	best := DSatur(graph)
+	bestColors := best.Colors()
+
+	coloring := make(Coloring)
+	var search func(used int)
+	search = func(used int) {
+		if len(coloring) == len(verts) {
+			if used < bestColors {
+				best = make(Coloring, len(coloring))
+				for v, c := range coloring {
+					best[v] = c
+				}
+				bestColors = used
+			}
+			return
+		}
+
+		v := mostSaturated(verts, neighbors, coloring)
+		taken := make(map[int]bool)
+		for _, n := range neighbors[v] {
+			if c, ok := coloring[n]; ok {
+				taken[c] = true
+			}
+		}
+		// only try one new color, any new color is as good as any other
+		for c := 0; c <= used && c < bestColors-1; c++ {
+			if taken[c] {
+				continue
+			}
+			coloring[v] = c
+			if c == used {
+				search(used + 1)
+			} else {
+				search(used)
+			}
+			delete(coloring, v)
+		}
+	}
+	search(0)
+
+	return bestColors, best
+}
+
+// BipartiteEdgeColoring colors the edges of a bipartite graph, treated as undirected,
+// so that no two edges sharing a vertex have the same color, using as many colors as
+// the largest degree. Edges running both ways between two vertices count as one edge
+// and get the same color. Returns ErrNotBipartite if the graph isn't bipartite
+func BipartiteEdgeColoring(graph DirectedGraph) (map[Edge]int, error) {
+	neighbors := undirectedNeighbors(graph)
+	if _, ok := bipartition(graph.Vertices(), neighbors); !ok {
+		return nil, ErrNotBipartite
+	}
+
+	// at[v][c] is the neighbour joined to v by an edge of color c
+	at := make(map[Vertex]map[int]Vertex, len(neighbors))
+	for v := range neighbors {
+		at[v] = make(map[int]Vertex)
+	}
+	free := func(v Vertex) int {
+		c := 0
+		for _, ok := at[v][c]; ok; _, ok = at[v][c] {
+			c++
+		}
+		return c
+	}
+
+	for _, u := range graph.Vertices() {
+		for _, v := range neighbors[u] {
+			if _, done := edgeColor(at, u, v); done {
+				continue
+			}
+			a, b := free(u), free(v)
+			if _, ok := at[v][a]; ok {
+				// a is used at v, so swap a and b along the path of a, b, a... colored edges
+				// from v. In a bipartite graph this path can't reach u, so a is then free at both
+				path := []Vertex{v}
+				for x, c := v, a; ; {
+					next, ok := at[x][c]
+					if !ok {
+						break
+					}
+					path = append(path, next)
+					x = next
+					if c == a {
+						c = b
+					} else {
+						c = a
+					}
+				}
+				for i := 0; i < len(path)-1; i++ {
+					delete(at[path[i]], colorBetween(at, path[i], path[i+1]))
+					delete(at[path[i+1]], colorBetween(at, path[i+1], path[i]))
+				}
+				for i := 0; i < len(path)-1; i++ {
+					c := b
+					if i%2 == 1 {
+						c = a
+					}
+					at[path[i]][c] = path[i+1]
+					at[path[i+1]][c] = path[i]
+				}
+			}
+			at[u][a] = v
+			at[v][a] = u
+		}
+	}
+
+	colors := make(map[Edge]int)
+	for v, es := range graph.Edges() {
+		for _, e := range es {
+			if c, ok := edgeColor(at, v, e.To()); ok {
+				colors[e] = c
+			}
+		}
+	}
+	return colors, nil
+}
+
+func edgeColor(at map[Vertex]map[int]Vertex, u, v Vertex) (int, bool) {
+	for c, n := range at[u] {
+		if n == v {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+func colorBetween(at map[Vertex]map[int]Vertex, u, v Vertex) int {
+	c, _ := edgeColor(at, u, v)
+	return c
+}
+
+// bipartition splits the vertices into two sides with no neighbours on the same side,
+// returning false if that isn't possible
+func bipartition(verts []Vertex, neighbors map[Vertex][]Vertex) (map[Vertex]bool, bool) {
+	side := make(map[Vertex]bool, len(verts))
+	for _, root := range verts {
+		if _, ok := side[root]; ok {
+			continue
+		}
+		side[root] = false
+		queue := []Vertex{root}
+		for len(queue) > 0 {
+			u := queue[0]
+			queue = queue[1:]
+			for _, n := range neighbors[u] {
+				if s, ok := side[n]; !ok {
+					side[n] = !side[u]
+					queue = append(queue, n)
+				} else if s == side[u] {
+					return nil, false
+				}
+			}
+		}
+	}
+	return side, true
+}
@@ -0,0 +1,371 @@
+package graph
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// labelPropagationRounds is the most passes LabelPropagation makes over the vertices,
+// it usually settles in a handful but can oscillate
+var labelPropagationRounds = 100
+
+// weightedNeighbor is an undirected neighbour and the total weight of the edges between
+type weightedNeighbor struct {
+	to     int
+	weight float64
+}
+
+// symmetricAdjacency treats a weighted digraph as undirected, numbering the vertices
+// in the order of verts and giving each its neighbours, in the same order, with the
+// summed weight of the edges either way between them. A self loop counts both ways so
+// has twice its weight
+func symmetricAdjacency(graph WeightedDigraph, verts []Vertex) [][]weightedNeighbor {
+	index := vertexIndex(verts)
+	weights := make([]map[int]float64, len(verts))
+	for i := range weights {
+		weights[i] = make(map[int]float64)
+	}
+	for v, es := range graph.Edges() {
+		for _, e := range es {
+			w := float64(graph.Weights()[e])
+			weights[index[v]][index[e.To()]] += w
+			weights[index[e.To()]][index[v]] += w
+		}
+	}
+
+	return sortedNeighbors(weights)
+}
+
+// sortedNeighbors turns a weight map for each vertex into neighbour lists in vertex order
+func sortedNeighbors(weights []map[int]float64) [][]weightedNeighbor {
+	adj := make([][]weightedNeighbor, len(weights))
+	for i, ws := range weights {
+		to := make([]int, 0, len(ws))
+		for j := range ws {
+			to = append(to, j)
+		}
+		sort.Ints(to)
+		for _, j := range to {
+			adj[i] = append(adj[i], weightedNeighbor{to: j, weight: ws[j]})
+		}
+	}
+	return adj
+}
+
+// modularity scores a numbered partition of an undirected weighted graph
+func modularity(adj [][]weightedNeighbor, community []int) float64 {
+	total := 0.0
+	degrees := make(map[int]float64)
+	inside := make(map[int]float64)
+	for i, ns := range adj {
+		for _, n := range ns {
+			total += n.weight
+			degrees[community[i]] += n.weight
+			if community[i] == community[n.to] {
+				inside[community[i]] += n.weight
+			}
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+
+	q := 0.0
+	for c, d := range degrees {
+		q += inside[c]/total - (d/total)*(d/total)
+	}
+	return q
+}
+
+// Modularity scores how well a partition splits the graph, treated as undirected with
+// the edges' weights, into communities: the fraction of the weight inside communities
+// less the fraction expected if the edges were placed at random. It ranges from -1/2
+// to 1, and higher is better
+func Modularity(graph WeightedDigraph, partition map[Vertex]int) float64 {
+	verts := graph.Vertices()
+	community := make([]int, len(verts))
+	for i, v := range verts {
+		community[i] = partition[v]
+	}
+	return modularity(symmetricAdjacency(graph, verts), community)
+}
+
+// partitionOf renumbers the communities from 0, in the order of their first vertex
+func partitionOf(verts []Vertex, community []int) map[Vertex]int {
+	ids := make(map[int]int)
+	partition := make(map[Vertex]int, len(verts))
+	for i, v := range verts {
+		id, ok := ids[community[i]]
+		if !ok {
+			id = len(ids)
+			ids[community[i]] = id
+		}
+		partition[v] = id
+	}
+	return partition
+}
+
+// Louvain partitions the graph, treated as undirected with the edges' weights, into
+// communities by greedily optimising modularity. Each vertex in turn moves to the
+// neighbouring community that most improves modularity until none moves, then each
+// community is merged into a single vertex and the process repeats on the smaller
+// graph. The order vertices are visited in is shuffled with the given seed.
+// Returns the partition and its modularity
+func Louvain(graph WeightedDigraph, seed int64) (map[Vertex]int, float64) {
+	verts := graph.Vertices()
+	original := symmetricAdjacency(graph, verts)
+	rng := rand.New(rand.NewSource(seed))
+
+	// community of each original vertex, and the graph of communities at this level
+	community := make([]int, len(verts))
+	for i := range community {
+		community[i] = i
+	}
+	adj := original
+	for {
+		moved := louvainMoves(adj, rng)
+
+		// number the communities in use, and stop once nothing merged
+		ids := make(map[int]int)
+		for _, c := range moved {
+			if _, ok := ids[c]; !ok {
+				ids[c] = len(ids)
+			}
+		}
+		for i, c := range community {
+			community[i] = ids[moved[c]]
+		}
+		if len(ids) == len(adj) {
+			break
+		}
+
+		weights := make([]map[int]float64, len(ids))
+		for i := range weights {
+			weights[i] = make(map[int]float64)
+		}
+		for i, ns := range adj {
+			for _, n := range ns {
+				weights[ids[moved[i]]][ids[moved[n.to]]] += n.weight
+			}
+		}
+		adj = sortedNeighbors(weights)
+	}
+
+	return partitionOf(verts, community), modularity(original, community)
+}
+
+// louvainMoves is the first phase of Louvain, returning the community of each vertex
+// after vertices stop moving between them
+func louvainMoves(adj [][]weightedNeighbor, rng *rand.Rand) []int {
+	n := len(adj)
+	community := make([]int, n)
+	degree := make([]float64, n)
+	total := make([]float64, n) // summed degree of each community
+	m2 := 0.0
+	for i, ns := range adj {
+		community[i] = i
+		for _, nb := range ns {
+			degree[i] += nb.weight
+		}
+		total[i] = degree[i]
+		m2 += degree[i]
+	}
+	if m2 == 0 {
+		return community
+	}
+
+	for moved := true; moved; {
+		moved = false
+		for _, i := range rng.Perm(n) {
+			// the weight from i to each neighbouring community, in the order first seen
+			from := community[i]
+			links := make(map[int]float64)
+			order := []int{from}
+			links[from] = 0
+			for _, nb := range adj[i] {
+				if nb.to == i {
+					continue
+				}
+				c := community[nb.to]
+				if _, ok := links[c]; !ok {
+					order = append(order, c)
+				}
+				links[c] += nb.weight
+			}
+
+			total[from] -= degree[i]
+			best := from
+			bestGain := links[from] - total[from]*degree[i]/m2
+			for _, c := range order[1:] {
+				if gain := links[c] - total[c]*degree[i]/m2; gain > bestGain {
+					best = c
+					bestGain = gain
+				}
+			}
+			total[best] += degree[i]
+			if best != from {
+				community[i] = best
+				moved = true
+			}
+		}
+	}
+	return community
+}
+
+// LabelPropagation partitions the graph, treated as undirected with the edges' weights,
+// by giving every vertex its own label and then repeatedly, in an order shuffled with
+// the given seed, relabelling each vertex with the label that has the most weight among
+// its neighbours, until no label changes. It is fast but doesn't optimise modularity
+// directly. Returns the partition and its modularity
+func LabelPropagation(graph WeightedDigraph, seed int64) (map[Vertex]int, float64) {
+	verts := graph.Vertices()
+	adj := symmetricAdjacency(graph, verts)
+	rng := rand.New(rand.NewSource(seed))
+
+	labels := make([]int, len(verts))
+	for i := range labels {
+		labels[i] = i
+	}
+	for round := 0; round < labelPropagationRounds; round++ {
+		changed := false
+		for _, i := range rng.Perm(len(verts)) {
+			weights := make(map[int]float64)
+			order := make([]int, 0)
+			for _, nb := range adj[i] {
+				if nb.to == i {
+					continue
+				}
+				l := labels[nb.to]
+				if _, ok := weights[l]; !ok {
+					order = append(order, l)
+				}
+				weights[l] += nb.weight
+			}
+			if len(order) == 0 {
+				continue
+			}
+
+			// keep the current label if it's one of the best, otherwise pick one at random
+			bestWeight := 0.0
+			best := make([]int, 0)
+			for _, l := range order {
+				switch w := weights[l]; {
+				case w > bestWeight || len(best) == 0:
+					bestWeight = w
+					best = append(best[:0], l)
+				case w == bestWeight:
+					best = append(best, l)
+				}
+			}
+			if containsInt(best, labels[i]) {
+				continue
+			}
+			labels[i] = best[rng.Intn(len(best))]
+			changed = true
+		}
+		if !changed {
+			break
+		}
+	}
+
+	return partitionOf(verts, labels), modularity(adj, labels)
+}
+
+// GirvanNewman partitions the graph, treated as undirected, by repeatedly removing the
+// edge with the highest betweenness (counted in hops), which tends to be one bridging
+// two communities. Each time the graph splits into more components the split is
+// scored by modularity, using the edges' weights, and the best split is returned along
+// with its modularity. It recomputes betweenness after every removal, so is slow on
+// large graphs
+func GirvanNewman(graph WeightedDigraph) (map[Vertex]int, float64) {
+	verts := graph.Vertices()
+	original := symmetricAdjacency(graph, verts)
+	index := vertexIndex(verts)
+	neighbors := undirectedNeighbors(graph)
+	adj := make([][]int, len(verts))
+	for i, v := range verts {
+		for _, n := range neighbors[v] {
+			adj[i] = append(adj[i], index[n])
+		}
+	}
+
+	best := intComponents(adj)
+	bestQ := modularity(original, best)
+	components := maxInt(best) + 1
+	for {
+		_, edges := brandes(adj)
+		cut, most := [2]int{-1, -1}, -1.0
+		for u := range adj {
+			for _, w := range adj[u] {
+				if u > w {
+					continue
+				}
+				if b := edges[[2]int{u, w}] + edges[[2]int{w, u}]; b > most {
+					cut, most = [2]int{u, w}, b
+				}
+			}
+		}
+		if cut[0] < 0 {
+			break
+		}
+		adj[cut[0]] = removeInt(adj[cut[0]], cut[1])
+		adj[cut[1]] = removeInt(adj[cut[1]], cut[0])
+
+		split := intComponents(adj)
+		if n := maxInt(split) + 1; n > components {
+			components = n
+			if q := modularity(original, split); q > bestQ {
+				best, bestQ = split, q
+			}
+		}
+	}
+
+	return partitionOf(verts, best), bestQ
+}
+
+// intComponents numbers the connected components of an undirected adjacency list
+func intComponents(adj [][]int) []int {
+	component := make([]int, len(adj))
+	for i := range component {
+		component[i] = -1
+	}
+	next := 0
+	for s := range adj {
+		if component[s] >= 0 {
+			continue
+		}
+		component[s] = next
+		stack := []int{s}
+		for len(stack) > 0 {
+			v := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			for _, w := range adj[v] {
+				if component[w] < 0 {
+					component[w] = next
+					stack = append(stack, w)
+				}
+			}
+		}
+		next++
+	}
+	return component
+}
+
+func maxInt(xs []int) int {
+	m := -1
+	for _, x := range xs {
+		if x > m {
+			m = x
+		}
+	}
+	return m
+}
+
+func removeInt(xs []int, x int) []int {
+	for i, y := range xs {
+		if y == x {
+			return append(xs[:i:i], xs[i+1:]...)
+		}
+	}
+	return xs
+}
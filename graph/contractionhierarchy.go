@@ -0,0 +1,349 @@
+package graph
+
+import (
+	"encoding/gob"
+	"io"
+
+	"github.com/DaJobat/gogve/priorityqueue"
+)
+
+// chEdge is an edge in a contraction hierarchy, between vertex indices. Shortcuts
+// remember the two edges they replace so that paths can be unpacked
+type chEdge struct {
+	From, To      int
+	Weight        float32
+	First, Second int // the edges a shortcut replaces, -1 for an edge of the graph
+}
+
+// witnessSettleLimit caps how far witness searches look while contracting, if one gives
+// up early a shortcut is added that may not be needed, which is safe but slower to query
+var witnessSettleLimit = 500
+
+// ContractionHierarchy is preprocessing for very fast repeated shortest path queries on
+// a graph that doesn't change. Every vertex is given a rank, and vertices are removed
+// ("contracted") from least to most important, adding shortcut edges between their
+// neighbours wherever the shortest path went through them. A query is then a
+// bidirectional Dijkstra that only ever moves up the ranks, which visits a tiny part of
+// the graph.
+//
+// Vertices are stored by their position in graph.Vertices(), so the graph must return
+// its vertices in the same order every time for a saved hierarchy to be loaded again.
+type ContractionHierarchy struct {
+	graph    WeightedDigraph
+	vertices []Vertex
+	index    map[Vertex]int
+	rank     []int
+	edges    []chEdge
+	up       [][]int // up[v] are edges from v to higher ranked vertices
+	down     [][]int // down[v] are edges into v from higher ranked vertices
+}
+
+// NewContractionHierarchy contracts graph, ordering vertices by edge difference: the number
+// of shortcuts contracting a vertex would add, less the edges it would remove
+func NewContractionHierarchy(graph WeightedDigraph) *ContractionHierarchy {
+	verts := graph.Vertices()
+	ch := ContractionHierarchy{
+		graph:    graph,
+		vertices: verts,
+		index:    vertexIndex(verts),
+		rank:     make([]int, len(verts)),
+		edges:    make([]chEdge, 0),
+	}
+
+	// keep only the cheapest of any parallel edges
+	out := make([]map[int]int, len(verts))
+	in := make([]map[int]int, len(verts))
+	for i := range verts {
+		out[i] = make(map[int]int)
+		in[i] = make(map[int]int)
+	}
+	weights := graph.Weights()
+	for v, es := range graph.Edges() {
+		from := ch.index[v]
+		for _, e := range es {
+			to := ch.index[e.To()]
+			if from == to {
+				continue
+			}
+			if existing, ok := out[from][to]; ok && ch.edges[existing].Weight <= weights[e] {
+				continue
+			}
+			out[from][to] = len(ch.edges)
+			in[to][from] = len(ch.edges)
+			ch.edges = append(ch.edges, chEdge{From: from, To: to, Weight: weights[e], First: -1, Second: -1})
+		}
+	}
+
+	contracted := make([]bool, len(verts))
+	deleted := make([]int, len(verts)) // contracted neighbours, to spread contraction out
+	queue := priorityqueue.NewBinaryHeap(priorityqueue.MinFirst)
+	for v := range verts {
+		queue.Push(v, float64(ch.edgeDifference(v, out, in, contracted, deleted)))
+	}
+
+	for rank := 0; queue.Len() > 0; {
+		key, _ := queue.Pop()
+		v := key.(int)
+		// lazy update, if this vertex got worse since it was queued put it back
+		if queue.Len() > 0 {
+			p := float64(ch.edgeDifference(v, out, in, contracted, deleted))
+			if _, front := queue.Peek(); p > front {
+				queue.Push(v, p)
+				continue
+			}
+		}
+
+		for _, s := range ch.shortcuts(v, out, in, contracted) {
+			if existing, ok := out[s.From][s.To]; ok && ch.edges[existing].Weight <= s.Weight {
+				continue
+			}
+			out[s.From][s.To] = len(ch.edges)
+			in[s.To][s.From] = len(ch.edges)
+			ch.edges = append(ch.edges, s)
+		}
+
+		contracted[v] = true
+		ch.rank[v] = rank
+		rank++
+		for u := range in[v] {
+			deleted[u]++
+		}
+		for w := range out[v] {
+			deleted[w]++
+		}
+	}
+
+	ch.buildSearchGraph()
+	return &ch
+}
+
+func (ch *ContractionHierarchy) buildSearchGraph() {
+	ch.up = make([][]int, len(ch.vertices))
+	ch.down = make([][]int, len(ch.vertices))
+	for i, e := range ch.edges {
+		if ch.rank[e.To] > ch.rank[e.From] {
+			ch.up[e.From] = append(ch.up[e.From], i)
+		} else {
+			ch.down[e.To] = append(ch.down[e.To], i)
+		}
+	}
+}
+
+func (ch *ContractionHierarchy) edgeDifference(v int, out, in []map[int]int, contracted []bool, deleted []int) float32 {
+	removed := 0
+	for u := range in[v] {
+		if !contracted[u] {
+			removed++
+		}
+	}
+	for w := range out[v] {
+		if !contracted[w] {
+			removed++
+		}
+	}
+	return float32(len(ch.shortcuts(v, out, in, contracted)) - removed + deleted[v])
+}
+
+// shortcuts returns the shortcuts needed to contract v, one for each pair of neighbours u, w
+// where u -> v -> w is the only shortest path from u to w among the uncontracted vertices
+func (ch *ContractionHierarchy) shortcuts(v int, out, in []map[int]int, contracted []bool) []chEdge {
+	needed := make([]chEdge, 0)
+	for u, ui := range in[v] {
+		if contracted[u] {
+			continue
+		}
+		var max float32
+		for w, wi := range out[v] {
+			if !contracted[w] && w != u && ch.edges[ui].Weight+ch.edges[wi].Weight > max {
+				max = ch.edges[ui].Weight + ch.edges[wi].Weight
+			}
+		}
+		witness := ch.witnessSearch(u, v, max, out, contracted)
+
+		for w, wi := range out[v] {
+			if contracted[w] || w == u {
+				continue
+			}
+			via := ch.edges[ui].Weight + ch.edges[wi].Weight
+			if d, ok := witness[w]; ok && d <= via {
+				continue
+			}
+			needed = append(needed, chEdge{From: u, To: w, Weight: via, First: ui, Second: wi})
+		}
+	}
+	return needed
+}
+
+// witnessSearch is a limited dijkstra from u that avoids v and stops past max
+func (ch *ContractionHierarchy) witnessSearch(u, v int, max float32, out []map[int]int, contracted []bool) map[int]float32 {
+	dist := map[int]float32{u: 0}
+	settled := 0
+	queue := priorityqueue.NewBinaryHeap(priorityqueue.MinFirst)
+	queue.Push(u, 0)
+	for queue.Len() > 0 && settled < witnessSettleLimit {
+		key, _ := queue.Pop()
+		x := key.(int)
+		if dist[x] > max {
+			break
+		}
+		settled++
+		for w, wi := range out[x] {
+			if w == v || contracted[w] {
+				continue
+			}
+			d := dist[x] + ch.edges[wi].Weight
+			if old, ok := dist[w]; !ok || d < old {
+				dist[w] = d
+				queue.Update(w, float64(d))
+			}
+		}
+	}
+	return dist
+}
+
+// Rank returns the contraction rank of v, higher ranked vertices are more important
+func (ch *ContractionHierarchy) Rank(v Vertex) int {
+	return ch.rank[ch.index[v]]
+}
+
+// Shortcuts returns the number of shortcut edges the hierarchy added to the graph
+func (ch *ContractionHierarchy) Shortcuts() int {
+	n := 0
+	for _, e := range ch.edges {
+		if e.First >= 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Path finds the shortest path from source to destination with a bidirectional search
+// of the hierarchy, and unpacks any shortcuts it used into edges of the graph
+func (ch *ContractionHierarchy) Path(source, destination Vertex) (Path, bool) {
+	s, sok := ch.index[source]
+	t, tok := ch.index[destination]
+	if !sok || !tok {
+		return Path{}, false
+	}
+
+	type side struct {
+		dist    map[int]float32
+		parent  map[int]int // edge used to reach each vertex
+		queue   priorityqueue.Queue
+		edges   [][]int
+		forward bool
+	}
+	sides := [2]*side{
+		{dist: map[int]float32{s: 0}, parent: map[int]int{}, queue: priorityqueue.NewBinaryHeap(priorityqueue.MinFirst), edges: ch.up, forward: true},
+		{dist: map[int]float32{t: 0}, parent: map[int]int{}, queue: priorityqueue.NewBinaryHeap(priorityqueue.MinFirst), edges: ch.down},
+	}
+	sides[0].queue.Push(s, 0)
+	sides[1].queue.Push(t, 0)
+
+	best, meet := float32Inf, -1
+	for sides[0].queue.Len() > 0 || sides[1].queue.Len() > 0 {
+		for _, sd := range sides {
+			if sd.queue.Len() == 0 {
+				continue
+			}
+			key, _ := sd.queue.Pop()
+			v := key.(int)
+			if sd.dist[v] >= best {
+				// nothing from here can beat the best meeting so far
+				sd.queue = priorityqueue.NewBinaryHeap(priorityqueue.MinFirst)
+				continue
+			}
+
+			other := sides[0]
+			if sd == sides[0] {
+				other = sides[1]
+			}
+			if d, ok := other.dist[v]; ok && sd.dist[v]+d < best {
+				best, meet = sd.dist[v]+d, v
+			}
+
+			for _, ei := range sd.edges[v] {
+				e := ch.edges[ei]
+				next := e.To
+				if !sd.forward {
+					next = e.From
+				}
+				d := sd.dist[v] + e.Weight
+				if old, ok := sd.dist[next]; !ok || d < old {
+					sd.dist[next] = d
+					sd.parent[next] = ei
+					sd.queue.Update(next, float64(d))
+				}
+			}
+		}
+	}
+
+	if meet < 0 {
+		return Path{}, false
+	}
+
+	// collect the hierarchy edges from source up to the meeting vertex and back down
+	hierarchyEdges := make([]int, 0)
+	for v := meet; v != s; v = ch.edges[sides[0].parent[v]].From {
+		hierarchyEdges = append(hierarchyEdges, sides[0].parent[v])
+	}
+	for i, j := 0, len(hierarchyEdges)-1; i < j; i, j = i+1, j-1 {
+		hierarchyEdges[i], hierarchyEdges[j] = hierarchyEdges[j], hierarchyEdges[i]
+	}
+	for v := meet; v != t; v = ch.edges[sides[1].parent[v]].To {
+		hierarchyEdges = append(hierarchyEdges, sides[1].parent[v])
+	}
+
+	verts := []Vertex{source}
+	for _, ei := range hierarchyEdges {
+		verts = ch.unpack(ei, verts)
+	}
+	return pathFromVertices(ch.graph, verts)
+}
+
+// unpack appends the vertices after the start of edge ei, expanding shortcuts
+func (ch *ContractionHierarchy) unpack(ei int, verts []Vertex) []Vertex {
+	e := ch.edges[ei]
+	if e.First < 0 {
+		return append(verts, ch.vertices[e.To])
+	}
+	return ch.unpack(e.Second, ch.unpack(e.First, verts))
+}
+
+type contractionData struct {
+	Vertices int
+	Rank     []int
+	Edges    []chEdge
+}
+
+// Save writes the hierarchy to w so it can be loaded with LoadContractionHierarchy
+func (ch *ContractionHierarchy) Save(w io.Writer) error {
+	return gob.NewEncoder(w).Encode(contractionData{
+		Vertices: len(ch.vertices),
+		Rank:     ch.rank,
+		Edges:    ch.edges,
+	})
+}
+
+// LoadContractionHierarchy reads a hierarchy written by Save, for use with the same graph
+func LoadContractionHierarchy(r io.Reader, graph WeightedDigraph) (*ContractionHierarchy, error) {
+	var data contractionData
+	if err := gob.NewDecoder(r).Decode(&data); err != nil {
+		return nil, err
+	}
+
+	verts := graph.Vertices()
+	if data.Vertices != len(verts) {
+		return nil, ErrGraphMismatch
+	}
+
+	ch := ContractionHierarchy{
+		graph:    graph,
+		vertices: verts,
+		index:    vertexIndex(verts),
+		rank:     data.Rank,
+		edges:    data.Edges,
+	}
+	ch.buildSearchGraph()
+	return &ch, nil
+}
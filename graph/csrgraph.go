@@ -0,0 +1,301 @@
+package graph
+
+import (
+	"math"
+)
+
+// CSRGraph is an immutable compressed sparse row copy of a weighted digraph, for graphs
+// that are searched far more often than they change. Vertices are numbered from 0 in
+// the order of the original graph's Vertices, and each vertex's out edges sit together
+// in flat slices of targets and weights, so searches by id avoid the map lookups and
+// allocations of the map based graphs.
+//
+// It is also a WeightedDigraph itself, so the other algorithms can be run on it, but
+// its Edges and Weights maps are built on first use and those searches are no faster
+type CSRGraph struct {
+	csrAdjacency
+	vertices []Vertex
+	index    map[Vertex]int
+	weights  []float64
+	edges    []Edge  // the original edge behind each slot of targets
+	from     []int32 // the source vertex of each slot of targets
+
+	edgeMap   map[Vertex][]Edge
+	weightMap map[Edge]float32
+}
+
+// NewCSRGraph copies graph, which panics if an edge leads to a vertex not in its Vertices
+func NewCSRGraph(graph WeightedDigraph) *CSRGraph {
+	verts := graph.Vertices()
+	c := CSRGraph{
+		vertices: verts,
+		index:    vertexIndex(verts),
+	}
+	c.offsets = make([]int, len(verts)+1)
+	edges := graph.Edges()
+	for i, v := range verts {
+		c.offsets[i+1] = c.offsets[i] + len(edges[v])
+	}
+
+	n := c.offsets[len(verts)]
+	c.targets = make([]int32, 0, n)
+	c.weights = make([]float64, 0, n)
+	c.edges = make([]Edge, 0, n)
+	c.from = make([]int32, 0, n)
+	weights := graph.Weights()
+	for i, v := range verts {
+		for _, e := range edges[v] {
+			to, ok := c.index[e.To()]
+			if !ok {
+				panic("edge to a vertex not in graph")
+			}
+			c.targets = append(c.targets, int32(to))
+			c.weights = append(c.weights, float64(weights[e]))
+			c.edges = append(c.edges, e)
+			c.from = append(c.from, int32(i))
+		}
+	}
+	return &c
+}
+
+// Order returns the number of vertices
+func (c *CSRGraph) Order() int {
+	return len(c.vertices)
+}
+
+// Size returns the number of edges
+func (c *CSRGraph) Size() int {
+	return len(c.targets)
+}
+
+// ID returns the id of v, or false if it isn't in the graph
+func (c *CSRGraph) ID(v Vertex) (int, bool) {
+	id, ok := c.index[v]
+	return id, ok
+}
+
+// Vertex returns the vertex with the given id
+func (c *CSRGraph) Vertex(id int) Vertex {
+	return c.vertices[id]
+}
+
+// OutEdges returns the targets and weights of the edges leaving id, as slices
+// of the graph's own storage which must not be changed
+func (c *CSRGraph) OutEdges(id int) ([]int32, []float64) {
+	return c.targets[c.offsets[id]:c.offsets[id+1]], c.weights[c.offsets[id]:c.offsets[id+1]]
+}
+
+func (c *CSRGraph) Vertices() []Vertex {
+	return c.vertices
+}
+
+func (c *CSRGraph) Edges() map[Vertex][]Edge {
+	if c.edgeMap == nil {
+		c.edgeMap = make(map[Vertex][]Edge, len(c.vertices))
+		for i, v := range c.vertices {
+			c.edgeMap[v] = c.edges[c.offsets[i]:c.offsets[i+1]:c.offsets[i+1]]
+		}
+	}
+	return c.edgeMap
+}
+
+func (c *CSRGraph) Weights() map[Edge]float32 {
+	if c.weightMap == nil {
+		c.weightMap = make(map[Edge]float32, len(c.edges))
+		for i, e := range c.edges {
+			c.weightMap[e] = float32(c.weights[i])
+		}
+	}
+	return c.weightMap
+}
+
+func (c *CSRGraph) AddEdge(from, to Vertex) {
+	panic("cannot add edges to a CSR graph")
+}
+
+func (c *CSRGraph) RemoveEdge(Edge) {
+	panic("cannot remove edges from a CSR graph")
+}
+
+// CSRSearch is the result of a search of a CSRGraph, indexed by vertex id. Distance is
+// +Inf for vertices that weren't reached, and Parent is -1 for those and the source
+type CSRSearch struct {
+	graph      *CSRGraph
+	Distance   []float64
+	Parent     []int32
+	parentEdge []int32
+}
+
+func newCSRSearch(c *CSRGraph, source int) *CSRSearch {
+	s := CSRSearch{
+		graph:      c,
+		Distance:   make([]float64, len(c.vertices)),
+		Parent:     make([]int32, len(c.vertices)),
+		parentEdge: make([]int32, len(c.vertices)),
+	}
+	for i := range s.Distance {
+		s.Distance[i] = math.Inf(1)
+		s.Parent[i] = -1
+		s.parentEdge[i] = -1
+	}
+	s.Distance[source] = 0
+	return &s
+}
+
+// Reached returns true if the search reached id
+func (s *CSRSearch) Reached(id int) bool {
+	return !math.IsInf(s.Distance[id], 1)
+}
+
+// IDPath returns the ids of the vertices from the source to id, or nil if id wasn't reached
+func (s *CSRSearch) IDPath(id int) []int {
+	if !s.Reached(id) {
+		return nil
+	}
+	ids := []int{id}
+	for s.Parent[id] >= 0 {
+		id = int(s.Parent[id])
+		ids = append(ids, id)
+	}
+	for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+		ids[i], ids[j] = ids[j], ids[i]
+	}
+	return ids
+}
+
+// Path returns the path from the source to id through the original graph's vertices
+// and edges, or false if id wasn't reached
+func (s *CSRSearch) Path(id int) (Path, bool) {
+	if !s.Reached(id) {
+		return Path{}, false
+	}
+	slots := make([]int32, 0)
+	for s.parentEdge[id] >= 0 {
+		slots = append(slots, s.parentEdge[id])
+		id = int(s.Parent[id])
+	}
+
+	p := Path{
+		Vertices: []Vertex{s.graph.vertices[id]},
+		Edges:    make([]Edge, 0, len(slots)),
+	}
+	for i := len(slots) - 1; i >= 0; i-- {
+		e := s.graph.edges[slots[i]]
+		p.Vertices = append(p.Vertices, e.To())
+		p.Edges = append(p.Edges, e)
+		p.Cost += float32(s.graph.weights[slots[i]])
+	}
+	return p, true
+}
+
+func (s *CSRSearch) reach(slot int32, to int32, distance float64) {
+	s.Distance[to] = distance
+	s.Parent[to] = s.graph.from[slot]
+	s.parentEdge[to] = slot
+}
+
+// BFS searches breadth first from source, Distance is the number of edges
+func (c *CSRGraph) BFS(source int) *CSRSearch {
+	s := newCSRSearch(c, source)
+	queue := make([]int32, 0, len(c.vertices))
+	queue = append(queue, int32(source))
+	for head := 0; head < len(queue); head++ {
+		v := queue[head]
+		for slot := c.offsets[v]; slot < c.offsets[v+1]; slot++ {
+			to := c.targets[slot]
+			if math.IsInf(s.Distance[to], 1) {
+				s.reach(int32(slot), to, s.Distance[v]+1)
+				queue = append(queue, to)
+			}
+		}
+	}
+	return s
+}
+
+// Dijkstra finds the shortest paths from source to every vertex
+func (c *CSRGraph) Dijkstra(source int) *CSRSearch {
+	return c.AStar(source, -1, nil)
+}
+
+// AStar finds the shortest path from source to destination, guided by h, which
+// estimates the distance from a vertex id to the destination and must never
+// overestimate it. A nil h makes it Dijkstra stopping at the destination, and a
+// destination of -1 searches the whole graph
+func (c *CSRGraph) AStar(source, destination int, h func(id int) float64) *CSRSearch {
+	s := newCSRSearch(c, source)
+	settled := make([]bool, len(c.vertices))
+	queue := csrHeap{{id: int32(source), priority: estimate(h, source)}}
+	for len(queue) > 0 {
+		v := queue.pop().id
+		if settled[v] {
+			// a stale duplicate, pushed again with a lower cost
+			continue
+		}
+		settled[v] = true
+		if int(v) == destination {
+			break
+		}
+
+		for slot := c.offsets[v]; slot < c.offsets[v+1]; slot++ {
+			to := c.targets[slot]
+			if d := s.Distance[v] + c.weights[slot]; d < s.Distance[to] {
+				s.reach(int32(slot), to, d)
+				queue.push(csrItem{id: to, priority: d + estimate(h, int(to))})
+			}
+		}
+	}
+	return s
+}
+
+func estimate(h func(int) float64, id int) float64 {
+	if h == nil {
+		return 0
+	}
+	return h(id)
+}
+
+type csrItem struct {
+	id       int32
+	priority float64
+}
+
+// csrHeap is a binary min heap of vertex ids, written out rather than using
+// container/heap so pushing doesn't allocate an interface for every item
+type csrHeap []csrItem
+
+func (h *csrHeap) push(item csrItem) {
+	*h = append(*h, item)
+	q := *h
+	for i := len(q) - 1; i > 0; {
+		parent := (i - 1) / 2
+		if q[parent].priority <= q[i].priority {
+			break
+		}
+		q[parent], q[i] = q[i], q[parent]
+		i = parent
+	}
+}
+
+func (h *csrHeap) pop() csrItem {
+	q := *h
+	top := q[0]
+	last := len(q) - 1
+	q[0] = q[last]
+	q = q[:last]
+	for i := 0; ; {
+		smallest := i
+		if l := 2*i + 1; l < len(q) && q[l].priority < q[smallest].priority {
+			smallest = l
+		}
+		if r := 2*i + 2; r < len(q) && q[r].priority < q[smallest].priority {
+			smallest = r
+		}
+		if smallest == i {
+			break
+		}
+		q[i], q[smallest] = q[smallest], q[i]
+		i = smallest
+	}
+	*h = q
+	return top
+}
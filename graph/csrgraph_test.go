@@ -0,0 +1,75 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// newRandomWeightedGraph makes a graph of n vertices with n*degree edges between random
+// vertices, weighted from 1 to 100
+func newRandomWeightedGraph(n, degree int, seed int64) *testGraph {
+	r := rand.New(rand.NewSource(seed))
+	verts := make([]Vertex, n)
+	for i := range verts {
+		verts[i] = i
+	}
+	g := newTestGraph(verts...)
+	for i := 0; i < n*degree; i++ {
+		g.addWeightedEdge(r.Intn(n), r.Intn(n), float32(r.Intn(100)+1))
+	}
+	return g
+}
+
+func TestCSRGraphDijkstra(t *testing.T) {
+	g := newRandomWeightedGraph(2000, 4, 2)
+	c := NewCSRGraph(g)
+	attrs := Dijkstra(g, 0)
+	search := c.Dijkstra(0)
+	bfs := BreadthFirstSearch(g, 0)
+	cbfs := c.BFS(0)
+	for id, v := range g.Vertices() {
+		want := attrs[v].ShortestEstimateFromSource()
+		if !search.Reached(id) {
+			if !isInf32(want) {
+				t.Fatalf("vertex %v not reached", v)
+			}
+			continue
+		}
+		if float32(search.Distance[id]) != want {
+			t.Fatalf("vertex %v distance %f, expected %f", v, search.Distance[id], want)
+		}
+		if p, ok := search.Path(id); !ok || p.Cost != want || p.Vertices[len(p.Vertices)-1] != v {
+			t.Errorf("vertex %v has path %v", v, p)
+		}
+		if int(cbfs.Distance[id]) != bfs[v].distance {
+			t.Errorf("vertex %v bfs distance %f, expected %d", v, cbfs.Distance[id], bfs[v].distance)
+		}
+	}
+}
+
+func TestNewCSRGraphUnknownVertex(t *testing.T) {
+	g := newTestGraph(0, 1)
+	g.AddEdge(0, 2)
+	defer func() {
+		if recover() == nil {
+			t.Error("NewCSRGraph accepted an edge to a vertex not in the graph")
+		}
+	}()
+	NewCSRGraph(g)
+}
+
+func BenchmarkDijkstra(b *testing.B) {
+	g := newRandomWeightedGraph(50000, 4, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Dijkstra(g, 0)
+	}
+}
+
+func BenchmarkCSRGraphDijkstra(b *testing.B) {
+	c := NewCSRGraph(newRandomWeightedGraph(50000, 4, 1))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Dijkstra(0)
+	}
+}
@@ -0,0 +1,401 @@
+package graph
+
+import "math"
+
+// The cycles below are returned as the vertices around them, each listed once and
+// starting from the earliest in the graph's Vertices, so the last vertex has an edge
+// back to the first. A self loop is a cycle of one vertex
+
+// ElementaryCycles lists every elementary cycle of the graph, those that don't visit a
+// vertex twice, using Johnson's algorithm. Parallel edges don't make separate cycles.
+// A dense graph can have exponentially many cycles, so this is meant for small or
+// sparse ones
+func ElementaryCycles(graph DirectedGraph) [][]Vertex {
+	verts := graph.Vertices()
+	index := vertexIndex(verts)
+	adj := simpleAdjacency(graph, verts)
+	cycles := make([][]Vertex, 0)
+	report := func(stack []int) {
+		cycle := make([]Vertex, len(stack))
+		for i, v := range stack {
+			cycle[i] = verts[v]
+		}
+		cycles = append(cycles, cycle)
+	}
+
+	loops := make([]bool, len(verts))
+	for v, es := range graph.Edges() {
+		for _, e := range es {
+			if e.To() == v {
+				loops[index[v]] = true
+			}
+		}
+	}
+
+	blocked := make([]bool, len(verts))
+	blockers := make([]map[int]bool, len(verts)) // vertices to unblock when each is unblocked
+	inComponent := make([]bool, len(verts))
+	var unblock func(u int)
+	unblock = func(u int) {
+		blocked[u] = false
+		for w := range blockers[u] {
+			delete(blockers[u], w)
+			if blocked[w] {
+				unblock(w)
+			}
+		}
+	}
+	stack := make([]int, 0)
+	var circuit func(s, v int) bool
+	circuit = func(s, v int) bool {
+		found := false
+		stack = append(stack, v)
+		blocked[v] = true
+		for _, w := range adj[v] {
+			if !inComponent[w] {
+				continue
+			}
+			if w == s {
+				report(stack)
+				found = true
+			} else if !blocked[w] && circuit(s, w) {
+				found = true
+			}
+		}
+		if found {
+			unblock(v)
+		} else {
+			// v stays blocked until one of its neighbours finds a way back to s
+			for _, w := range adj[v] {
+				if inComponent[w] {
+					blockers[w][v] = true
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		return found
+	}
+
+	// each cycle is found from its earliest vertex s, searching only the strongly
+	// connected component of s among the vertices from s on
+	for s := range verts {
+		if loops[s] {
+			report([]int{s})
+		}
+		component := componentFrom(adj, s)
+		if len(component) < 2 {
+			continue
+		}
+		for _, v := range component {
+			inComponent[v] = true
+			blocked[v] = false
+			blockers[v] = make(map[int]bool)
+		}
+		circuit(s, s)
+		for _, v := range component {
+			inComponent[v] = false
+		}
+	}
+	return cycles
+}
+
+// componentFrom returns the strongly connected component of s in the graph induced by
+// the vertices numbered s and above: those reachable from s that can also reach it
+func componentFrom(adj [][]int, s int) []int {
+	forward := map[int]bool{s: true}
+	stack := []int{s}
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, w := range adj[v] {
+			if w > s && !forward[w] {
+				forward[w] = true
+				stack = append(stack, w)
+			}
+		}
+	}
+
+	reverse := make(map[int][]int)
+	for v := range forward {
+		for _, w := range adj[v] {
+			if forward[w] {
+				reverse[w] = append(reverse[w], v)
+			}
+		}
+	}
+	component := []int{s}
+	backward := map[int]bool{s: true}
+	stack = append(stack, s)
+	for len(stack) > 0 {
+		v := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, u := range reverse[v] {
+			if !backward[u] {
+				backward[u] = true
+				component = append(component, u)
+				stack = append(stack, u)
+			}
+		}
+	}
+	return component
+}
+
+// MinimumMeanCycle finds the cycle with the lowest mean edge weight, using Karp's
+// algorithm on each strongly connected component, and returns it as a path from its
+// earliest vertex back to itself along with its mean. A negative mean means the graph
+// has a negative cycle, so negating the weights finds the most profitable loop per
+// step. Returns false if the graph has no cycles. Each component of n vertices takes
+// O(n^2) memory
+func MinimumMeanCycle(graph WeightedDigraph) (Path, float32, bool) {
+	edges := graph.Edges()
+	weights := graph.Weights()
+	order := vertexIndex(graph.Vertices())
+
+	var best []Edge
+	bestMean := math.Inf(1)
+	for _, component := range StronglyConnectedComponents(graph) {
+		index := vertexIndex(component)
+		inner := make([]Edge, 0)
+		for _, v := range component {
+			for _, e := range edges[v] {
+				if _, ok := index[e.To()]; ok {
+					inner = append(inner, e)
+				}
+			}
+		}
+		if len(inner) == 0 {
+			continue
+		}
+		if cycle, mean := karp(component, index, inner, weights); mean < bestMean {
+			best, bestMean = cycle, mean
+		}
+	}
+	if best == nil {
+		return Path{}, 0, false
+	}
+
+	// start from the earliest vertex
+	first := 0
+	for i, e := range best {
+		if order[e.From()] < order[best[first].From()] {
+			first = i
+		}
+	}
+	p := Path{Vertices: []Vertex{best[first].From()}}
+	for i := range best {
+		e := best[(first+i)%len(best)]
+		p.Vertices = append(p.Vertices, e.To())
+		p.Edges = append(p.Edges, e)
+		p.Cost += weights[e]
+	}
+	return p, float32(bestMean), true
+}
+
+// karp finds the minimum mean cycle of a strongly connected component with the given
+// edges, returning its edges in order and its mean
+func karp(verts []Vertex, index map[Vertex]int, edges []Edge, weights map[Edge]float32) ([]Edge, float64) {
+	n := len(verts)
+	// walk[k][v] is the lightest walk of exactly k edges ending at v, starting
+	// anywhere, and last[k][v] the edge it ends with
+	walk := make([][]float64, n+1)
+	last := make([][]int, n+1)
+	for k := range walk {
+		walk[k] = make([]float64, n)
+		last[k] = make([]int, n)
+		for v := range walk[k] {
+			if k > 0 {
+				walk[k][v] = math.Inf(1)
+			}
+			last[k][v] = -1
+		}
+	}
+	for k := 1; k <= n; k++ {
+		for i, e := range edges {
+			from, to := index[e.From()], index[e.To()]
+			if w := walk[k-1][from] + float64(weights[e]); w < walk[k][to] {
+				walk[k][to] = w
+				last[k][to] = i
+			}
+		}
+	}
+
+	// the minimum mean is the least over v of the greatest over k of
+	// (walk[n][v] - walk[k][v]) / (n - k)
+	end, mean := -1, math.Inf(1)
+	for v := 0; v < n; v++ {
+		if math.IsInf(walk[n][v], 1) {
+			continue
+		}
+		worst := math.Inf(-1)
+		for k := 0; k < n; k++ {
+			if !math.IsInf(walk[k][v], 1) {
+				worst = math.Max(worst, (walk[n][v]-walk[k][v])/float64(n-k))
+			}
+		}
+		if worst < mean {
+			end, mean = v, worst
+		}
+	}
+
+	// the n edge walk to end must repeat a vertex, and its cycles include one
+	// with the minimum mean
+	path := make([]int, n)
+	for k, v := n, end; k > 0; k-- {
+		path[k-1] = last[k][v]
+		v = index[edges[last[k][v]].From()]
+	}
+	// split the walk into simple cycles by keeping the edges of the simple path so far
+	// and cutting a cycle off whenever it comes back to a vertex on it
+	var best []int
+	bestMean := math.Inf(1)
+	kept := make([]int, 0, n)
+	position := map[int]int{index[edges[path[0]].From()]: 0} // where each vertex on the path starts
+	for _, ei := range path {
+		kept = append(kept, ei)
+		to := index[edges[ei].To()]
+		j, ok := position[to]
+		if !ok {
+			position[to] = len(kept)
+			continue
+		}
+		total := 0.0
+		for _, c := range kept[j:] {
+			total += float64(weights[edges[c]])
+			delete(position, index[edges[c].To()])
+		}
+		if m := total / float64(len(kept)-j); m < bestMean {
+			best, bestMean = append([]int(nil), kept[j:]...), m
+		}
+		kept = kept[:j]
+		position[to] = j
+	}
+
+	cycle := make([]Edge, len(best))
+	for i, ei := range best {
+		cycle[i] = edges[ei]
+	}
+	return cycle, bestMean
+}
+
+// ShortestCycle finds the lightest cycle of the graph, a Dijkstra search from every
+// vertex back to itself, so the weights must not be negative. The path runs from the
+// cycle's earliest vertex back to itself. Returns false if the graph has no cycles
+func ShortestCycle(graph WeightedDigraph) (Path, bool) {
+	c := NewCSRGraph(graph)
+	incoming := make([][]int32, c.Order()) // the edge slots into each vertex
+	for slot, to := range c.targets {
+		incoming[to] = append(incoming[to], int32(slot))
+	}
+
+	var best *CSRSearch
+	bestSlot, bestCost := int32(-1), math.Inf(1)
+	for s := 0; s < c.Order(); s++ {
+		search := c.Dijkstra(s)
+		for _, slot := range incoming[s] {
+			from := c.from[slot]
+			if cost := search.Distance[from] + c.weights[slot]; cost < bestCost {
+				best, bestSlot, bestCost = search, slot, cost
+			}
+		}
+	}
+	if best == nil {
+		return Path{}, false
+	}
+
+	p, _ := best.Path(int(c.from[bestSlot]))
+	e := c.edges[bestSlot]
+	p.Vertices = append(p.Vertices, e.To())
+	p.Edges = append(p.Edges, e)
+	p.Cost += float32(c.weights[bestSlot])
+	return p, true
+}
+
+// Girth returns the fewest edges in any cycle of the graph, or 0 if it has no cycles
+func Girth(graph WeightedDigraph) int {
+	p, ok := ShortestCycle(ReweightEdges(graph, func(Edge, float32) float32 {
+		return 1
+	}))
+	if !ok {
+		return 0
+	}
+	return p.Len()
+}
+
+// CycleBasis returns a fundamental cycle basis of the graph, treated as undirected: one
+// cycle for each edge left out of a breadth first spanning forest, made of that edge and
+// the forest's path between its ends. Every cycle of the graph can be made by combining
+// these, taking the edges used an odd number of times. Edges either way between two
+// vertices count as one, and a self loop is a cycle of its own
+func CycleBasis(graph DirectedGraph) [][]Vertex {
+	verts := graph.Vertices()
+	index := vertexIndex(verts)
+	neighbors := undirectedNeighbors(graph)
+	parent := make(map[Vertex]Vertex, len(verts))
+	depth := make(map[Vertex]int, len(verts))
+
+	cycles := make([][]Vertex, 0)
+	for _, root := range verts {
+		if _, seen := depth[root]; seen {
+			continue
+		}
+		depth[root] = 0
+		queue := []Vertex{root}
+		for len(queue) > 0 {
+			v := queue[0]
+			queue = queue[1:]
+			for _, n := range neighbors[v] {
+				if _, seen := depth[n]; !seen {
+					depth[n] = depth[v] + 1
+					parent[n] = v
+					queue = append(queue, n)
+				}
+			}
+		}
+	}
+
+	edges := graph.Edges()
+	for _, v := range verts {
+		for _, e := range edges[v] {
+			if e.To() == v {
+				cycles = append(cycles, []Vertex{v})
+				break
+			}
+		}
+	}
+	for _, v := range verts {
+		for _, n := range neighbors[v] {
+			if index[n] < index[v] || parent[n] == v || parent[v] == n {
+				continue
+			}
+			// climb from both ends to where their tree paths meet
+			up, down := []Vertex{v}, []Vertex{n}
+			a, b := v, n
+			for a != b {
+				if depth[a] >= depth[b] {
+					a = parent[a]
+					up = append(up, a)
+				} else {
+					b = parent[b]
+					down = append(down, b)
+				}
+			}
+			cycle := up
+			for i := len(down) - 2; i >= 0; i-- {
+				cycle = append(cycle, down[i])
+			}
+			cycles = append(cycles, rotateToEarliest(cycle, index))
+		}
+	}
+	return cycles
+}
+
+// rotateToEarliest turns a cycle round so it starts from its earliest vertex
+func rotateToEarliest(cycle []Vertex, index map[Vertex]int) []Vertex {
+	first := 0
+	for i, v := range cycle {
+		if index[v] < index[cycle[first]] {
+			first = i
+		}
+	}
+	return append(cycle[first:len(cycle):len(cycle)], cycle[:first]...)
+}
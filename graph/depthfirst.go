@@ -37,10 +37,10 @@ func (d *DFSAttribute) SetPredecessor(pre Vertex) {
 
 type DFSTree map[Vertex]*DFSAttribute
 
-var time int
+var dfsTime int
 
 func DepthFirstSearch(graph DirectedGraph, source Vertex) DFSTree {
-	time = 0
+	dfsTime = 0
 	attrs := make(DFSTree)
 	for _, u := range graph.Vertices() {
 		attrs[u] = &DFSAttribute{
@@ -57,17 +57,17 @@ func DepthFirstSearch(graph DirectedGraph, source Vertex) DFSTree {
 		}
 	}
 
-	time = 0
+	dfsTime = 0
 	return attrs
 }
 
 func dfsVisit(graph DirectedGraph, attrs DFSTree, u Vertex) {
-	time = time + 1
-	attrs[u].discoverTime = time
-	attrs[u].lowestReachable = time
+	dfsTime = dfsTime + 1
+	attrs[u].discoverTime = dfsTime
+	attrs[u].lowestReachable = dfsTime
 	attrs[u].color = BFSGray
 
-	for _, edge := range graph.Edges()[u] {
+	for _, edge := range edgesFrom(graph, u) {
 		if edge.To() == attrs[u].predecessor {
 			continue
 		}
@@ -93,8 +93,8 @@ func dfsVisit(graph DirectedGraph, attrs DFSTree, u Vertex) {
 	}
 
 	attrs[u].color = BFSBlack
-	time = time + 1
-	attrs[u].finishTime = time
+	dfsTime = dfsTime + 1
+	attrs[u].finishTime = dfsTime
 }
 
 func min(x, y int) int {
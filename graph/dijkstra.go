@@ -1,8 +1,9 @@
 package graph
 
 import (
-	"container/heap"
 	"math"
+
+	"github.com/DaJobat/gogve/priorityqueue"
 )
 
 type WeightedDigraph interface {
@@ -71,7 +72,7 @@ func Relax(graph WeightedDigraph, edge Edge, attrs RelaxableAttributes) bool {
 	// check if this edge gives us a shorter path than the previous path to
 	// the vertex we're going to
 	changed := false
-	fromSEFS := fromAttr.ShortestEstimateFromSource() + graph.Weights()[edge]
+	fromSEFS := fromAttr.ShortestEstimateFromSource() + edgeWeight(graph, edge)
 	if toAttr.ShortestEstimateFromSource() > fromSEFS {
 		toAttr.SetShortestEstimateFromSource(fromSEFS)
 		toAttr.SetPredecessor(edge.From())
@@ -87,40 +88,33 @@ func Dijkstra(graph WeightedDigraph, source Vertex) RelaxableAttributes {
 	return dijkstraLoop(graph, attrs, nil)
 }
 
+// DijkstraLimited is Dijkstra which stops early if it hits any of the given limits,
+// returning the attributes of the vertices settled so far and a *SearchStoppedError
+func DijkstraLimited(graph WeightedDigraph, source Vertex, opts ...SearchOption) (RelaxableAttributes, error) {
+	limits := newSearchLimits(opts)
+	attrs := initSingleSource(graph, source)
+	outs := dijkstraLoop(graph, attrs, func(v Vertex) bool {
+		return limits.stop(attrs[v].ShortestEstimateFromSource())
+	})
+	return outs, limits.err
+}
+
 func dijkstraLoop(graph WeightedDigraph, attributes RelaxableAttributes, breakCondition func(Vertex) bool) RelaxableAttributes {
-	queue, vvpm := initDijkstraQueue(graph, attributes)
-	outs := make(RelaxableAttributes)
-
-	for queue.Len() > 0 {
-		nextShortest := heap.Pop(queue).(*VertexPriorityItem)
-		relaxed := false
-		for _, edge := range graph.Edges()[nextShortest.vertex] {
-			relaxed = Relax(graph, edge, attributes) || relaxed
-			heap.Fix(queue, vvpm[edge.To()].index)
+	state := newDijkstraState(graph, attributes)
+	for !state.Done() {
+		if breakCondition != nil && breakCondition(state.Next()) {
+			break
 		}
-
-		outs[nextShortest.vertex] = attributes[nextShortest.vertex]
+		state.Step()
 	}
-	return outs
+	return state.outs
 }
 
-type vertexVPItemMap map[Vertex]*VertexPriorityItem
-
-func initDijkstraQueue(graph WeightedDigraph, attrs RelaxableAttributes) (*MinPriorityQueue, vertexVPItemMap) {
-	queue := make(MinPriorityQueue, len(graph.Vertices()))
-	vvpm := make(vertexVPItemMap)
-	for i, v := range graph.Vertices() {
-		attr := attrs[v].(*DijkstraAttribute)
-		queue[i] = &VertexPriorityItem{
-			vertex:   v,
-			index:    i,
-			priority: &attr.ShortestEstimate,
-		}
-
-		vvpm[v] = queue[i]
+// initDijkstraQueue queues every vertex at its current shortest estimate
+func initDijkstraQueue(graph WeightedDigraph, attrs RelaxableAttributes) priorityqueue.Queue {
+	queue := priorityqueue.NewBinaryHeap(priorityqueue.MinFirst)
+	for _, v := range graph.Vertices() {
+		queue.Push(v, float64(attrs[v].ShortestEstimateFromSource()))
 	}
-
-	heap.Init(&queue)
-
-	return &queue, vvpm
+	return queue
 }
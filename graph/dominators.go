@@ -0,0 +1,230 @@
+package graph
+
+// DominatorTree records, for every vertex reachable from a root, its immediate dominator:
+// the last vertex other than itself that every path from the root to it must pass
+// through. For a post-dominator tree the paths run from each vertex to the root instead
+type DominatorTree struct {
+	root     Vertex
+	idom     map[Vertex]Vertex
+	children map[Vertex][]Vertex
+	enter    map[Vertex]int // dfs times in the dominator tree, for dominance queries
+	exit     map[Vertex]int
+	preds    map[Vertex][]Vertex // predecessors in the direction the tree was built
+	order    []Vertex            // the reachable vertices in reverse postorder
+}
+
+// Dominators builds the dominator tree of the vertices reachable from root, using the
+// iterative algorithm of Cooper, Harvey and Kennedy
+func Dominators(graph DirectedGraph, root Vertex) *DominatorTree {
+	succs := make(map[Vertex][]Vertex)
+	preds := make(map[Vertex][]Vertex)
+	for v, es := range graph.Edges() {
+		for _, e := range es {
+			succs[v] = append(succs[v], e.To())
+			preds[e.To()] = append(preds[e.To()], v)
+		}
+	}
+	return newDominatorTree(graph, root, succs, preds)
+}
+
+// PostDominators builds the post-dominator tree of the vertices that can reach exit,
+// which is the dominator tree of the reversed graph. A graph with several exits
+// should have them all lead to a single exit vertex first
+func PostDominators(graph DirectedGraph, exit Vertex) *DominatorTree {
+	succs := make(map[Vertex][]Vertex)
+	preds := make(map[Vertex][]Vertex)
+	for v, es := range graph.Edges() {
+		for _, e := range es {
+			succs[e.To()] = append(succs[e.To()], v)
+			preds[v] = append(preds[v], e.To())
+		}
+	}
+	return newDominatorTree(graph, exit, succs, preds)
+}
+
+func newDominatorTree(graph DirectedGraph, root Vertex, succs, preds map[Vertex][]Vertex) *DominatorTree {
+	found := false
+	for _, v := range graph.Vertices() {
+		if v == root {
+			found = true
+			break
+		}
+	}
+	if !found {
+		panic("root not in graph")
+	}
+
+	// number the reachable vertices in postorder, the root is numbered last
+	postorder := make([]Vertex, 0)
+	number := make(map[Vertex]int)
+	visited := map[Vertex]bool{root: true}
+	stack := []*dfsFrame{{vertex: root}}
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		if top.next < len(succs[top.vertex]) {
+			next := succs[top.vertex][top.next]
+			top.next++
+			if !visited[next] {
+				visited[next] = true
+				stack = append(stack, &dfsFrame{vertex: next})
+			}
+			continue
+		}
+		stack = stack[:len(stack)-1]
+		number[top.vertex] = len(postorder)
+		postorder = append(postorder, top.vertex)
+	}
+
+	idom := map[Vertex]Vertex{root: root}
+	intersect := func(a, b Vertex) Vertex {
+		for a != b {
+			for number[a] < number[b] {
+				a = idom[a]
+			}
+			for number[b] < number[a] {
+				b = idom[b]
+			}
+		}
+		return a
+	}
+	for changed := true; changed; {
+		changed = false
+		// reverse postorder, skipping the root
+		for i := len(postorder) - 2; i >= 0; i-- {
+			v := postorder[i]
+			var newIdom Vertex
+			for _, p := range preds[v] {
+				if _, ok := idom[p]; !ok {
+					continue
+				}
+				if newIdom == nil {
+					newIdom = p
+				} else {
+					newIdom = intersect(p, newIdom)
+				}
+			}
+			if idom[v] != newIdom {
+				idom[v] = newIdom
+				changed = true
+			}
+		}
+	}
+
+	dt := DominatorTree{
+		root:     root,
+		idom:     idom,
+		children: make(map[Vertex][]Vertex),
+		enter:    make(map[Vertex]int),
+		exit:     make(map[Vertex]int),
+		preds:    preds,
+		order:    make([]Vertex, 0, len(postorder)),
+	}
+	delete(dt.idom, root)
+	// children go in reverse postorder, so a tree walk follows the graph's order
+	for i := len(postorder) - 1; i >= 0; i-- {
+		v := postorder[i]
+		dt.order = append(dt.order, v)
+		if v != root {
+			dt.children[idom[v]] = append(dt.children[idom[v]], v)
+		}
+	}
+
+	time := 0
+	treeStack := []*dfsFrame{{vertex: root}}
+	dt.enter[root] = time
+	for len(treeStack) > 0 {
+		top := treeStack[len(treeStack)-1]
+		if top.next < len(dt.children[top.vertex]) {
+			child := dt.children[top.vertex][top.next]
+			top.next++
+			time++
+			dt.enter[child] = time
+			treeStack = append(treeStack, &dfsFrame{vertex: child})
+			continue
+		}
+		treeStack = treeStack[:len(treeStack)-1]
+		time++
+		dt.exit[top.vertex] = time
+	}
+
+	return &dt
+}
+
+func (dt *DominatorTree) Root() Vertex {
+	return dt.root
+}
+
+// ImmediateDominator returns the immediate dominator of v, or false if v
+// is the root or can't be reached
+func (dt *DominatorTree) ImmediateDominator(v Vertex) (Vertex, bool) {
+	idom, ok := dt.idom[v]
+	return idom, ok
+}
+
+// Children returns the vertices that v immediately dominates
+func (dt *DominatorTree) Children(v Vertex) []Vertex {
+	return dt.children[v]
+}
+
+// Reachable returns true if v is in the tree
+func (dt *DominatorTree) Reachable(v Vertex) bool {
+	_, ok := dt.enter[v]
+	return ok
+}
+
+// Dominates returns true if every path from the root to b passes through a. Every
+// vertex dominates itself, and vertices that can't be reached are dominated by nothing
+func (dt *DominatorTree) Dominates(a, b Vertex) bool {
+	if !dt.Reachable(a) || !dt.Reachable(b) {
+		return false
+	}
+	return dt.enter[a] <= dt.enter[b] && dt.exit[b] <= dt.exit[a]
+}
+
+// Dominators returns every vertex that dominates v, from v up to the root
+func (dt *DominatorTree) Dominators(v Vertex) []Vertex {
+	if !dt.Reachable(v) {
+		return nil
+	}
+	doms := []Vertex{v}
+	for v != dt.root {
+		v = dt.idom[v]
+		doms = append(doms, v)
+	}
+	return doms
+}
+
+// Frontiers returns the dominance frontier of every vertex in the tree, the vertices
+// where its dominance stops: those it doesn't strictly dominate but does dominate a
+// predecessor of. For a post-dominator tree these are the control dependences
+func (dt *DominatorTree) Frontiers() map[Vertex][]Vertex {
+	frontiers := make(map[Vertex][]Vertex)
+	in := make(map[Vertex]map[Vertex]bool)
+	for _, v := range dt.order {
+		preds := make([]Vertex, 0, len(dt.preds[v]))
+		for _, p := range dt.preds[v] {
+			if dt.Reachable(p) {
+				preds = append(preds, p)
+			}
+		}
+		if len(preds) < 2 && !(len(preds) == 1 && v == dt.root) {
+			continue
+		}
+		stop, hasIdom := dt.idom[v]
+		for _, p := range preds {
+			for runner := p; !hasIdom || runner != stop; runner = dt.idom[runner] {
+				if in[runner] == nil {
+					in[runner] = make(map[Vertex]bool)
+				}
+				if !in[runner][v] {
+					in[runner][v] = true
+					frontiers[runner] = append(frontiers[runner], v)
+				}
+				if runner == dt.root {
+					break
+				}
+			}
+		}
+	}
+	return frontiers
+}
@@ -0,0 +1,294 @@
+package graph
+
+import (
+	"container/heap"
+	"math"
+)
+
+var float32Inf = float32(math.Inf(1))
+
+// dStarKey is the two part priority used by D* Lite, compared lexicographically
+type dStarKey [2]float32
+
+func (k dStarKey) less(k1 dStarKey) bool {
+	return k[0] < k1[0] || (k[0] == k1[0] && k[1] < k1[1])
+}
+
+type dStarItem struct {
+	vertex Vertex
+	key    dStarKey
+	index  int
+}
+
+// dStarQueue is an indexed heap of vertices by dStarKey. It stays on container/heap
+// rather than priorityqueue, whose priorities are a single float64: the two float32
+// parts can't be packed into one float64 that orders the same way, and ordering by the
+// first part alone breaks the ties D* Lite relies on the second part for
+type dStarQueue struct {
+	items []*dStarItem
+	index map[Vertex]*dStarItem
+}
+
+func (q *dStarQueue) Len() int {
+	return len(q.items)
+}
+
+func (q *dStarQueue) Less(i, j int) bool {
+	return q.items[i].key.less(q.items[j].key)
+}
+
+func (q *dStarQueue) Swap(i, j int) {
+	q.items[i], q.items[j] = q.items[j], q.items[i]
+	q.items[i].index = i
+	q.items[j].index = j
+}
+
+func (q *dStarQueue) Push(x interface{}) {
+	item := x.(*dStarItem)
+	item.index = len(q.items)
+	q.items = append(q.items, item)
+	q.index[item.vertex] = item
+}
+
+func (q *dStarQueue) Pop() interface{} {
+	n := len(q.items)
+	item := q.items[n-1]
+	q.items[n-1] = nil
+	q.items = q.items[:n-1]
+	item.index = -1
+	delete(q.index, item.vertex)
+	return item
+}
+
+func (q *dStarQueue) topKey() dStarKey {
+	if len(q.items) == 0 {
+		return dStarKey{float32Inf, float32Inf}
+	}
+	return q.items[0].key
+}
+
+func (q *dStarQueue) set(v Vertex, key dStarKey) {
+	if item, ok := q.index[v]; ok {
+		item.key = key
+		heap.Fix(q, item.index)
+		return
+	}
+	heap.Push(q, &dStarItem{vertex: v, key: key})
+}
+
+func (q *dStarQueue) remove(v Vertex) {
+	if item, ok := q.index[v]; ok {
+		heap.Remove(q, item.index)
+	}
+}
+
+// DStarLite is an incremental planner which finds the shortest path from a start
+// vertex to a goal, and then repairs that path as edges change or the start moves,
+// rather than searching from scratch. It searches backwards from the goal, so
+// only the vertices whose distances are affected by a change are revisited.
+//
+// The planner keeps hold of the graph it was created with; whenever an edge is
+// added, removed or reweighted in that graph the planner must be told with
+// UpdateEdge before the next call to Path.
+type DStarLite struct {
+	graph        WeightedDigraph
+	predecessors map[Vertex][]Edge
+	start        EstimatedVertex
+	lastStart    EstimatedVertex
+	goal         EstimatedVertex
+	km           float32 // accumulated heuristic offset from start moves
+	g            map[Vertex]float32
+	rhs          map[Vertex]float32
+	queue        *dStarQueue
+}
+
+func NewDStarLite(graph WeightedDigraph, start, goal EstimatedVertex) *DStarLite {
+	d := DStarLite{
+		graph:        graph,
+		predecessors: make(map[Vertex][]Edge),
+		start:        start,
+		lastStart:    start,
+		goal:         goal,
+		g:            make(map[Vertex]float32),
+		rhs:          make(map[Vertex]float32),
+		queue: &dStarQueue{
+			items: make([]*dStarItem, 0),
+			index: make(map[Vertex]*dStarItem),
+		},
+	}
+
+	for _, es := range graph.Edges() {
+		for _, e := range es {
+			d.predecessors[e.To()] = append(d.predecessors[e.To()], e)
+		}
+	}
+
+	d.rhs[goal] = 0
+	d.queue.set(goal, d.calculateKey(goal))
+
+	return &d
+}
+
+func (d *DStarLite) Start() EstimatedVertex {
+	return d.start
+}
+
+func (d *DStarLite) Goal() EstimatedVertex {
+	return d.goal
+}
+
+// G returns the current cost estimate from v to the goal
+func (d *DStarLite) G(v Vertex) float32 {
+	if g, ok := d.g[v]; ok {
+		return g
+	}
+	return float32Inf
+}
+
+func (d *DStarLite) getRHS(v Vertex) float32 {
+	if rhs, ok := d.rhs[v]; ok {
+		return rhs
+	}
+	return float32Inf
+}
+
+func (d *DStarLite) calculateKey(v Vertex) dStarKey {
+	m := d.G(v)
+	if rhs := d.getRHS(v); rhs < m {
+		m = rhs
+	}
+	return dStarKey{m + v.(EstimatedVertex).EstimatedDistance(d.start) + d.km, m}
+}
+
+// edgeCost returns the weight of an edge, or infinity if the edge is no longer in the graph
+func (d *DStarLite) edgeCost(e Edge) float32 {
+	if w, ok := d.graph.Weights()[e]; ok {
+		return w
+	}
+	return float32Inf
+}
+
+func (d *DStarLite) updateVertex(u Vertex) {
+	if u != d.goal {
+		rhs := float32Inf
+		for _, e := range d.graph.Edges()[u] {
+			if c := d.edgeCost(e) + d.G(e.To()); c < rhs {
+				rhs = c
+			}
+		}
+		d.rhs[u] = rhs
+	}
+
+	if d.G(u) != d.getRHS(u) {
+		d.queue.set(u, d.calculateKey(u))
+	} else {
+		d.queue.remove(u)
+	}
+}
+
+func (d *DStarLite) computeShortestPath() {
+	for d.queue.Len() > 0 &&
+		(d.queue.topKey().less(d.calculateKey(d.start)) || d.getRHS(d.start) != d.G(d.start)) {
+		u := d.queue.items[0].vertex
+		oldKey := d.queue.topKey()
+		newKey := d.calculateKey(u)
+
+		switch {
+		case oldKey.less(newKey):
+			// the key is out of date because the start has moved, so requeue it
+			d.queue.set(u, newKey)
+		case d.G(u) > d.getRHS(u):
+			// overconsistent, the vertex got cheaper so settle it
+			d.g[u] = d.getRHS(u)
+			d.queue.remove(u)
+			for _, e := range d.predecessors[u] {
+				d.updateVertex(e.From())
+			}
+		default:
+			// underconsistent, the vertex got more expensive so
+			// invalidate it and everything that relied on it
+			d.g[u] = float32Inf
+			d.updateVertex(u)
+			for _, e := range d.predecessors[u] {
+				d.updateVertex(e.From())
+			}
+		}
+	}
+}
+
+// MoveStart tells the planner that the start has moved (usually along the
+// previously returned path), the next call to Path will plan from here
+func (d *DStarLite) MoveStart(start EstimatedVertex) {
+	d.km += d.lastStart.EstimatedDistance(start)
+	d.lastStart = start
+	d.start = start
+}
+
+// UpdateEdge tells the planner that edge has been added to the graph, removed from it
+// or had its weight changed
+func (d *DStarLite) UpdateEdge(edge Edge) {
+	inGraph := false
+	for _, e := range d.graph.Edges()[edge.From()] {
+		if e == edge {
+			inGraph = true
+			break
+		}
+	}
+
+	preds := d.predecessors[edge.To()]
+	known := -1
+	for i, e := range preds {
+		if e == edge {
+			known = i
+			break
+		}
+	}
+	switch {
+	case inGraph && known < 0:
+		d.predecessors[edge.To()] = append(preds, edge)
+	case !inGraph && known >= 0:
+		d.predecessors[edge.To()] = append(preds[:known:known], preds[known+1:]...)
+	}
+
+	d.updateVertex(edge.From())
+}
+
+// UpdateWeight sets the weight of edge in the graph's weight map and notifies the planner.
+// This relies on the graph's Weights returning its own map rather than a copy
+func (d *DStarLite) UpdateWeight(edge Edge, weight float32) {
+	d.graph.Weights()[edge] = weight
+	d.UpdateEdge(edge)
+}
+
+// Path repairs the planner's search after any changes and returns the current shortest path
+// from the start to the goal, or false if the goal can't be reached
+func (d *DStarLite) Path() (Path, bool) {
+	d.computeShortestPath()
+	if d.G(d.start) == float32Inf {
+		return Path{}, false
+	}
+
+	p := Path{
+		Vertices: []Vertex{d.start},
+		Edges:    make([]Edge, 0),
+	}
+	for current := Vertex(d.start); current != d.goal; {
+		var best Edge
+		bestCost := float32Inf
+		for _, e := range d.graph.Edges()[current] {
+			if c := d.edgeCost(e) + d.G(e.To()); c < bestCost {
+				best = e
+				bestCost = c
+			}
+		}
+		if best == nil || len(p.Edges) > len(d.g) {
+			return Path{}, false
+		}
+		p.Edges = append(p.Edges, best)
+		p.Vertices = append(p.Vertices, best.To())
+		p.Cost += d.edgeCost(best)
+		current = best.To()
+	}
+
+	return p, true
+}
@@ -0,0 +1,491 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+)
+
+var (
+	ErrNoEulerianPath    = fmt.Errorf("graph has no eulerian path")
+	ErrNoEulerianCircuit = fmt.Errorf("graph has no eulerian circuit")
+	ErrNotConnected      = fmt.Errorf("graph edges are not all connected")
+	ErrNoPostmanRoute    = fmt.Errorf("graph has no route covering every edge")
+)
+
+// eulerEdge is an edge of the multigraph walked by hierholzer. For undirected walks
+// reverse is the edge running the other way, if the graph has one paired with this edge
+type eulerEdge struct {
+	edge    Edge
+	reverse Edge
+	from    Vertex
+	to      Vertex
+}
+
+func directedEulerEdges(graph DirectedGraph) []eulerEdge {
+	edges := make([]eulerEdge, 0)
+	for _, v := range graph.Vertices() {
+		for _, e := range graph.Edges()[v] {
+			edges = append(edges, eulerEdge{edge: e, from: e.From(), to: e.To()})
+		}
+	}
+	return edges
+}
+
+// undirectedEulerEdges treats each edge as walkable either way. Where the graph has
+// edges both ways between two vertices they are paired up and count as one undirected
+// edge, so undirected graphs can be stored either with one edge or two per connection
+func undirectedEulerEdges(graph DirectedGraph) ([]eulerEdge, map[Edge]Edge) {
+	edges := make([]eulerEdge, 0)
+	pairOf := make(map[Edge]Edge)
+	unpaired := make(map[[2]Vertex][]int)
+	for _, v := range graph.Vertices() {
+		for _, e := range graph.Edges()[v] {
+			back := [2]Vertex{e.To(), e.From()}
+			if e.From() != e.To() && len(unpaired[back]) > 0 {
+				i := unpaired[back][0]
+				unpaired[back] = unpaired[back][1:]
+				edges[i].reverse = e
+				pairOf[e] = edges[i].edge
+				pairOf[edges[i].edge] = e
+				continue
+			}
+			key := [2]Vertex{e.From(), e.To()}
+			unpaired[key] = append(unpaired[key], len(edges))
+			edges = append(edges, eulerEdge{edge: e, from: e.From(), to: e.To()})
+		}
+	}
+	return edges, pairOf
+}
+
+// hierholzer walks every edge exactly once starting from start, by following unused edges
+// until stuck and then splicing in detours from earlier vertices that still have unused
+// edges. If some edges can't be reached the returned walk won't contain them
+func hierholzer(start Vertex, edges []eulerEdge, undirected bool) ([]Vertex, []Edge) {
+	adj := make(map[Vertex][]int)
+	for i, e := range edges {
+		adj[e.from] = append(adj[e.from], i)
+		if undirected && e.from != e.to {
+			adj[e.to] = append(adj[e.to], i)
+		}
+	}
+
+	type step struct {
+		vertex Vertex
+		edge   Edge
+	}
+	used := make([]bool, len(edges))
+	next := make(map[Vertex]int)
+	stack := []step{{vertex: start}}
+	circuit := make([]step, 0, len(edges)+1)
+
+	for len(stack) > 0 {
+		top := stack[len(stack)-1]
+		v := top.vertex
+		for next[v] < len(adj[v]) && used[adj[v][next[v]]] {
+			next[v]++
+		}
+		if next[v] == len(adj[v]) {
+			circuit = append(circuit, top)
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		i := adj[v][next[v]]
+		used[i] = true
+		e := edges[i]
+		if e.from == v {
+			stack = append(stack, step{vertex: e.to, edge: e.edge})
+		} else if e.reverse != nil {
+			stack = append(stack, step{vertex: e.from, edge: e.reverse})
+		} else {
+			stack = append(stack, step{vertex: e.from, edge: e.edge})
+		}
+	}
+
+	verts := make([]Vertex, 0, len(circuit))
+	walked := make([]Edge, 0, len(edges))
+	for i := len(circuit) - 1; i >= 0; i-- {
+		verts = append(verts, circuit[i].vertex)
+		if circuit[i].edge != nil {
+			walked = append(walked, circuit[i].edge)
+		}
+	}
+	return verts, walked
+}
+
+// eulerStart checks the degrees of the multigraph and returns where an eulerian
+// path must start, or false if there is no eulerian path (or circuit if circuit is set)
+func eulerStart(verts []Vertex, edges []eulerEdge, undirected, circuit bool) (Vertex, bool) {
+	out := make(map[Vertex]int)
+	in := make(map[Vertex]int)
+	for _, e := range edges {
+		out[e.from]++
+		in[e.to]++
+	}
+
+	var start, first Vertex
+	starts, ends := 0, 0
+	for _, v := range verts {
+		if first == nil && out[v]+in[v] > 0 {
+			first = v
+		}
+		if undirected {
+			if (out[v]+in[v])%2 == 1 {
+				starts++
+				if start == nil {
+					start = v
+				}
+			}
+			continue
+		}
+		switch d := out[v] - in[v]; {
+		case d == 1:
+			starts++
+			start = v
+		case d == -1:
+			ends++
+		case d != 0:
+			return nil, false
+		}
+	}
+
+	switch {
+	case undirected && starts == 0, !undirected && starts == 0 && ends == 0:
+		if first == nil {
+			// no edges at all
+			first = verts[0]
+		}
+		return first, true
+	case circuit:
+		return nil, false
+	case undirected && starts == 2, !undirected && starts == 1 && ends == 1:
+		return start, true
+	default:
+		return nil, false
+	}
+}
+
+func eulerWalk(graph DirectedGraph, edges []eulerEdge, undirected, circuit bool) (Path, error) {
+	noWalk := ErrNoEulerianPath
+	if circuit {
+		noWalk = ErrNoEulerianCircuit
+	}
+	if len(graph.Vertices()) == 0 {
+		return Path{}, noWalk
+	}
+
+	start, ok := eulerStart(graph.Vertices(), edges, undirected, circuit)
+	if !ok {
+		return Path{}, noWalk
+	}
+
+	verts, walked := hierholzer(start, edges, undirected)
+	if len(walked) != len(edges) {
+		return Path{}, ErrNotConnected
+	}
+
+	p := Path{
+		Vertices: verts,
+		Edges:    walked,
+	}
+	if wg, ok := graph.(WeightedDigraph); ok {
+		weights := wg.Weights()
+		for _, e := range walked {
+			p.Cost += weights[e]
+		}
+	}
+	return p, nil
+}
+
+// EulerianPath finds a walk through the graph that uses every edge exactly once, using
+// Hierholzer's algorithm. If the graph has an eulerian circuit, that is returned
+func EulerianPath(graph DirectedGraph) (Path, error) {
+	return eulerWalk(graph, directedEulerEdges(graph), false, false)
+}
+
+// EulerianCircuit finds a walk through the graph that uses every edge exactly once and
+// finishes where it started
+func EulerianCircuit(graph DirectedGraph) (Path, error) {
+	return eulerWalk(graph, directedEulerEdges(graph), false, true)
+}
+
+// UndirectedEulerianPath is EulerianPath for a graph treated as undirected, where each
+// edge can be walked either way. A pair of edges running opposite ways between two
+// vertices counts as a single undirected edge. The returned path uses the edge running
+// the direction walked where there is one, otherwise the edge is walked backwards
+func UndirectedEulerianPath(graph DirectedGraph) (Path, error) {
+	edges, _ := undirectedEulerEdges(graph)
+	return eulerWalk(graph, edges, true, false)
+}
+
+// UndirectedEulerianCircuit is EulerianCircuit for a graph treated as undirected,
+// in the same way as UndirectedEulerianPath
+func UndirectedEulerianCircuit(graph DirectedGraph) (Path, error) {
+	edges, _ := undirectedEulerEdges(graph)
+	return eulerWalk(graph, edges, true, true)
+}
+
+// ChinesePostman finds the cheapest closed walk that uses every edge at least once.
+// Every vertex with more edges in than out is matched to vertices with more edges out
+// than in, at the least total shortest path cost, and the edges of those shortest paths
+// are walked twice. The graph must be strongly connected
+func ChinesePostman(graph WeightedDigraph) (Path, error) {
+	edges := directedEulerEdges(graph)
+	balance := make(map[Vertex]int)
+	for _, e := range edges {
+		balance[e.from]--
+		balance[e.to]++
+	}
+
+	// a vertex with a surplus of edges in needs extra paths leaving it, and the other way round
+	var surplus, deficit []Vertex
+	for _, v := range graph.Vertices() {
+		for i := 0; i < balance[v]; i++ {
+			surplus = append(surplus, v)
+		}
+		for i := 0; i > balance[v]; i-- {
+			deficit = append(deficit, v)
+		}
+	}
+
+	paths := make(map[Vertex]RelaxableAttributes)
+	for _, v := range surplus {
+		if _, ok := paths[v]; !ok {
+			paths[v] = Dijkstra(graph, v)
+		}
+	}
+	cost := make([][]float64, len(surplus))
+	for i, s := range surplus {
+		cost[i] = make([]float64, len(deficit))
+		for j, d := range deficit {
+			cost[i][j] = float64(paths[s][d].ShortestEstimateFromSource())
+			if math.IsInf(cost[i][j], 1) {
+				return Path{}, ErrNoPostmanRoute
+			}
+		}
+	}
+
+	for i, j := range minimumAssignment(cost) {
+		p, ok := PathFromAttributes(graph, paths[surplus[i]], surplus[i], deficit[j])
+		if !ok {
+			return Path{}, ErrNoPostmanRoute
+		}
+		for _, e := range p.Edges {
+			edges = append(edges, eulerEdge{edge: e, from: e.From(), to: e.To()})
+		}
+	}
+
+	p, err := eulerWalk(graph, edges, false, true)
+	if err != nil {
+		return Path{}, ErrNoPostmanRoute
+	}
+	return p, nil
+}
+
+// UndirectedChinesePostman is ChinesePostman for a graph treated as undirected, in the
+// same way as UndirectedEulerianPath. Vertices with an odd number of edges are paired up
+// at the least total shortest path cost, exactly if there are few enough of them
+// (exactMatchingLimit) and greedily, closest pairs first, otherwise
+func UndirectedChinesePostman(graph WeightedDigraph) (Path, error) {
+	edges, pairOf := undirectedEulerEdges(graph)
+	degree := make(map[Vertex]int)
+	for _, e := range edges {
+		degree[e.from]++
+		degree[e.to]++
+	}
+	odd := make([]Vertex, 0)
+	for _, v := range graph.Vertices() {
+		if degree[v]%2 == 1 {
+			odd = append(odd, v)
+		}
+	}
+
+	// searching for the shortest paths can use every edge either way
+	both := newReversedDigraph(graph)
+	for v, es := range graph.Edges() {
+		both.edges[v] = append(both.edges[v], es...)
+		for _, e := range es {
+			both.weights[e] = graph.Weights()[e]
+		}
+	}
+
+	paths := make([]RelaxableAttributes, len(odd))
+	dist := make([][]float64, len(odd))
+	for i, v := range odd {
+		paths[i] = Dijkstra(both, v)
+		dist[i] = make([]float64, len(odd))
+		for j, w := range odd {
+			dist[i][j] = float64(paths[i][w].ShortestEstimateFromSource())
+		}
+	}
+
+	for _, pair := range minimumPairing(dist) {
+		p, ok := PathFromAttributes(both, paths[pair[0]], odd[pair[0]], odd[pair[1]])
+		if !ok {
+			return Path{}, ErrNoPostmanRoute
+		}
+		for _, e := range p.Edges {
+			// reversed copies stand in for an edge the other way, walked backwards
+			if _, original := graph.Weights()[e]; !original {
+				e = originalOfReversed(graph, e)
+			}
+			edges = append(edges, eulerEdge{edge: e, reverse: pairOf[e], from: e.From(), to: e.To()})
+		}
+	}
+
+	p, err := eulerWalk(graph, edges, true, true)
+	if err != nil {
+		return Path{}, ErrNoPostmanRoute
+	}
+	return p, nil
+}
+
+// originalOfReversed finds the cheapest edge of graph that the reversed edge re was copied from
+func originalOfReversed(graph WeightedDigraph, re Edge) Edge {
+	p, _ := pathFromVertices(graph, []Vertex{re.To(), re.From()})
+	return p.Edges[0]
+}
+
+// minimumAssignment solves the assignment problem with the hungarian algorithm, returning
+// for each row the column assigned to it so that the total cost is smallest. There must
+// be no more rows than columns
+func minimumAssignment(cost [][]float64) []int {
+	n := len(cost)
+	if n == 0 {
+		return nil
+	}
+	m := len(cost[0])
+
+	// potentials and matching are 1 indexed, with 0 as a dummy
+	u := make([]float64, n+1)
+	v := make([]float64, m+1)
+	match := make([]int, m+1) // match[j] is the row assigned to column j
+	way := make([]int, m+1)
+	for i := 1; i <= n; i++ {
+		match[0] = i
+		j0 := 0
+		minv := make([]float64, m+1)
+		used := make([]bool, m+1)
+		for j := range minv {
+			minv[j] = math.Inf(1)
+		}
+		for match[j0] != 0 {
+			used[j0] = true
+			i0, delta, j1 := match[j0], math.Inf(1), 0
+			for j := 1; j <= m; j++ {
+				if used[j] {
+					continue
+				}
+				if cur := cost[i0-1][j-1] - u[i0] - v[j]; cur < minv[j] {
+					minv[j] = cur
+					way[j] = j0
+				}
+				if minv[j] < delta {
+					delta = minv[j]
+					j1 = j
+				}
+			}
+			for j := 0; j <= m; j++ {
+				if used[j] {
+					u[match[j]] += delta
+					v[j] -= delta
+				} else {
+					minv[j] -= delta
+				}
+			}
+			j0 = j1
+		}
+		for j0 != 0 {
+			j1 := way[j0]
+			match[j0] = match[j1]
+			j0 = j1
+		}
+	}
+
+	rows := make([]int, n)
+	for j := 1; j <= m; j++ {
+		if match[j] != 0 {
+			rows[match[j]-1] = j - 1
+		}
+	}
+	return rows
+}
+
+// exactMatchingLimit is the most vertices minimumPairing will match exactly,
+// the exact matching takes time and memory exponential in the number of vertices
+var exactMatchingLimit = 20
+
+// minimumPairing splits an even number of vertices into pairs with the smallest total
+// distance, given the matrix of distances between them
+func minimumPairing(dist [][]float64) [][2]int {
+	n := len(dist)
+	if n == 0 {
+		return nil
+	}
+
+	if n > exactMatchingLimit {
+		// greedily pair the closest remaining vertices
+		paired := make([]bool, n)
+		pairs := make([][2]int, 0, n/2)
+		for len(pairs) < n/2 {
+			bi, bj := -1, -1
+			for i := 0; i < n; i++ {
+				for j := i + 1; j < n; j++ {
+					if !paired[i] && !paired[j] && (bi < 0 || dist[i][j] < dist[bi][bj]) {
+						bi, bj = i, j
+					}
+				}
+			}
+			paired[bi], paired[bj] = true, true
+			pairs = append(pairs, [2]int{bi, bj})
+		}
+		return pairs
+	}
+
+	// best[mask] is the cheapest pairing of the vertices in mask, built up by always
+	// pairing the lowest vertex in the mask with each of the others
+	full := 1<<uint(n) - 1
+	best := make([]float64, full+1)
+	choice := make([]int, full+1)
+	for mask := 1; mask <= full; mask++ {
+		best[mask] = math.Inf(1)
+		if popcount(mask)%2 == 1 {
+			continue
+		}
+		i := lowestBit(mask)
+		for j := i + 1; j < n; j++ {
+			if mask&(1<<uint(j)) == 0 {
+				continue
+			}
+			if c := dist[i][j] + best[mask&^(1<<uint(i))&^(1<<uint(j))]; c < best[mask] {
+				best[mask] = c
+				choice[mask] = j
+			}
+		}
+	}
+
+	pairs := make([][2]int, 0, n/2)
+	for mask := full; mask != 0; {
+		i, j := lowestBit(mask), choice[mask]
+		pairs = append(pairs, [2]int{i, j})
+		mask &^= 1<<uint(i) | 1<<uint(j)
+	}
+	return pairs
+}
+
+func popcount(x int) int {
+	n := 0
+	for ; x != 0; x &= x - 1 {
+		n++
+	}
+	return n
+}
+
+func lowestBit(x int) int {
+	i := 0
+	for x&1 == 0 {
+		x >>= 1
+		i++
+	}
+	return i
+}
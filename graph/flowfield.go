@@ -0,0 +1,95 @@
+package graph
+
+import (
+	"math"
+
+	"github.com/DaJobat/gogve/util"
+)
+
+// DistanceField holds, for each vertex, the cost of getting from it to the nearest
+// goal. These are often called Dijkstra maps: an agent that repeatedly steps to its
+// cheapest neighbour will roll downhill to a goal
+type DistanceField map[Vertex]float32
+
+// DijkstraMap builds the distance field for a set of goals, each goal starts with the
+// given cost (usually zero, but a higher cost makes a goal less attractive), along with
+// the flow field that follows it downhill. The search runs over the reverse graph, so
+// costs are those of walking along the edges towards the goals. A goal reached more
+// cheaply from another goal is treated as an ordinary vertex
+func DijkstraMap(graph WeightedDigraph, goals map[Vertex]float32) (DistanceField, FlowField) {
+	reversed := newReversedDigraph(graph)
+	attrs := dijkstraLoop(reversed, initMultiSource(reversed, goals), nil)
+
+	df := make(DistanceField)
+	ff := make(FlowField)
+	weights := graph.Weights()
+	edges := graph.Edges()
+	for v, attr := range attrs {
+		df[v] = attr.ShortestEstimateFromSource()
+		// the predecessor in the reversed search is the next vertex towards a goal,
+		// goals have none
+		next := attr.Predecessor()
+		if next == nil {
+			continue
+		}
+		for _, e := range edges[v] {
+			if e.To() == next && (ff[v] == nil || weights[e] < weights[ff[v]]) {
+				ff[v] = e
+			}
+		}
+	}
+	return df, ff
+}
+
+// Flee builds a map for running away from the goals of this field. Every distance
+// is multiplied by -scale and the field is then rescanned, so agents rolling downhill
+// move away from the goals but, with a scale above 1, prefer escapes that lead
+// somewhere over dead ends close by. Unreachable vertices stay unreachable
+func (df DistanceField) Flee(graph WeightedDigraph, scale float32) (DistanceField, FlowField) {
+	starts := make(map[Vertex]float32)
+	for v, d := range df {
+		if !math.IsInf(float64(d), 1) {
+			starts[v] = d * -scale
+		}
+	}
+
+	return DijkstraMap(graph, starts)
+}
+
+// FlowField holds the edge each vertex should take to head towards the nearest goal,
+// following the shortest paths found by DijkstraMap. Goals, and vertices that cannot
+// reach a goal, have no entry
+type FlowField map[Vertex]Edge
+
+// Next returns the vertex to move to from v, or false if v is a goal or is stuck
+func (ff FlowField) Next(v Vertex) (Vertex, bool) {
+	e, ok := ff[v]
+	if !ok {
+		return nil, false
+	}
+	return e.To(), true
+}
+
+// Direction returns the unit vector pointing from v towards the next vertex,
+// using position to place vertices in space. Returns nil if there is no next vertex
+func (ff FlowField) Direction(v Vertex, position func(Vertex) util.FVec) util.FVec {
+	next, ok := ff.Next(v)
+	if !ok {
+		return nil
+	}
+
+	from, to := position(v), position(next)
+	members := make([]float64, from.Degree())
+	length := 0.0
+	for i := range members {
+		members[i] = to.M(i) - from.M(i)
+		length += members[i] * members[i]
+	}
+	if length = math.Sqrt(length); length > 0 {
+		for i := range members {
+			members[i] /= length
+		}
+	}
+
+	return util.NewFVec(from.Degree(), members...)
+}
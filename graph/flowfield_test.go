@@ -0,0 +1,30 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestFlowFieldZeroWeightEdges(t *testing.T) {
+	// goals 0 and 1 are joined by a free edge, as is 2 to 1, which comes first in the
+	// graph's vertices at the same distance as the goals
+	g := newTestGraph(2, 0, 1, 3)
+	g.addUndirectedEdge(0, 1, 0)
+	g.addUndirectedEdge(2, 1, 0)
+	g.addUndirectedEdge(3, 2, 1)
+
+	df, ff := DijkstraMap(g, map[Vertex]float32{0: 0, 1: 0})
+	for _, goal := range []Vertex{0, 1} {
+		if next, ok := ff.Next(goal); ok {
+			t.Errorf("goal %v flows on to %v", goal, next)
+		}
+	}
+	if next, ok := ff.Next(2); !ok || next != 1 {
+		t.Errorf("vertex 2 flows to %v, expected 1", next)
+	}
+	if next, ok := ff.Next(3); !ok || next != 2 {
+		t.Errorf("vertex 3 flows to %v, expected 2", next)
+	}
+	if df[3] != 1 {
+		t.Errorf("vertex 3 at distance %f, expected 1", df[3])
+	}
+}
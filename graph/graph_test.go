@@ -0,0 +1,56 @@
+package graph
+
+// testGraph is a small weighted digraph for the tests
+type testGraph struct {
+	vertices []Vertex
+	edges    map[Vertex][]Edge
+	weights  map[Edge]float32
+}
+
+func newTestGraph(vertices ...Vertex) *testGraph {
+	return &testGraph{
+		vertices: vertices,
+		edges:    make(map[Vertex][]Edge),
+		weights:  make(map[Edge]float32),
+	}
+}
+
+func (g *testGraph) Vertices() []Vertex {
+	return g.vertices
+}
+
+func (g *testGraph) Edges() map[Vertex][]Edge {
+	return g.edges
+}
+
+func (g *testGraph) Weights() map[Edge]float32 {
+	return g.weights
+}
+
+func (g *testGraph) AddEdge(from, to Vertex) {
+	g.addWeightedEdge(from, to, 1)
+}
+
+func (g *testGraph) addWeightedEdge(from, to Vertex, weight float32) Edge {
+	e := NewEdge(from, to)
+	g.edges[from] = append(g.edges[from], e)
+	g.weights[e] = weight
+	return e
+}
+
+// addUndirectedEdge adds an edge each way between a and b
+func (g *testGraph) addUndirectedEdge(a, b Vertex, weight float32) {
+	g.addWeightedEdge(a, b, weight)
+	g.addWeightedEdge(b, a, weight)
+}
+
+func (g *testGraph) RemoveEdge(edge Edge) {
+	es := g.edges[edge.From()]
+	for i, e := range es {
+		if e == edge {
+			g.edges[edge.From()] = append(es[:i:i], es[i+1:]...)
+			delete(g.weights, edge)
+			return
+		}
+	}
+}
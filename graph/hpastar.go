@@ -0,0 +1,448 @@
+package graph
+
+import (
+	"container/list"
+	"math"
+)
+
+// inducedDigraph is the part of a weighted digraph made up of a set of vertices
+// and the edges between them. The edge map is computed once at creation
+type inducedDigraph struct {
+	WeightedDigraph
+	vertices []Vertex
+	edges    map[Vertex][]Edge
+}
+
+func newInducedDigraph(graph WeightedDigraph, vertices []Vertex) *inducedDigraph {
+	in := make(map[Vertex]bool)
+	for _, v := range vertices {
+		in[v] = true
+	}
+
+	id := inducedDigraph{
+		WeightedDigraph: graph,
+		vertices:        vertices,
+		edges:           make(map[Vertex][]Edge),
+	}
+	edges := graph.Edges()
+	for _, v := range vertices {
+		kept := make([]Edge, 0, len(edges[v]))
+		for _, e := range edges[v] {
+			if in[e.To()] {
+				kept = append(kept, e)
+			}
+		}
+		id.edges[v] = kept
+	}
+
+	return &id
+}
+
+func (id *inducedDigraph) Vertices() []Vertex {
+	return id.vertices
+}
+
+func (id *inducedDigraph) Edges() map[Vertex][]Edge {
+	return id.edges
+}
+
+// GridPartition splits a grid shaped graph into square clusters of size x size cells,
+// coords gives the grid position of each vertex. Cluster ids are numbered in the order
+// the clusters are first found in graph.Vertices()
+func GridPartition(graph DirectedGraph, size int, coords func(Vertex) (x, y int)) map[Vertex]int {
+	if size < 1 {
+		panic("cluster size must be at least 1")
+	}
+
+	ids := make(map[[2]int]int)
+	partition := make(map[Vertex]int)
+	for _, v := range graph.Vertices() {
+		x, y := coords(v)
+		cell := [2]int{
+			int(math.Floor(float64(x) / float64(size))),
+			int(math.Floor(float64(y) / float64(size))),
+		}
+		id, ok := ids[cell]
+		if !ok {
+			id = len(ids)
+			ids[cell] = id
+		}
+		partition[v] = id
+	}
+
+	return partition
+}
+
+// BFSPartition splits a general graph into clusters of at most size vertices by growing
+// each cluster breadth first from the first unassigned vertex
+func BFSPartition(graph DirectedGraph, size int) map[Vertex]int {
+	if size < 1 {
+		panic("cluster size must be at least 1")
+	}
+
+	partition := make(map[Vertex]int)
+	edges := graph.Edges()
+	id := 0
+	for _, root := range graph.Vertices() {
+		if _, ok := partition[root]; ok {
+			continue
+		}
+
+		count := 0
+		queue := list.New()
+		queue.PushBack(root)
+		partition[root] = id
+		for queue.Len() > 0 && count < size {
+			u := queue.Remove(queue.Front()).(Vertex)
+			count++
+			for _, e := range edges[u] {
+				if _, ok := partition[e.To()]; !ok && count+queue.Len() < size {
+					partition[e.To()] = id
+					queue.PushBack(e.To())
+				}
+			}
+		}
+		id++
+	}
+
+	return partition
+}
+
+// abstractDigraph is the graph of cluster entrances searched by HPA*, each edge
+// remembers the concrete path it stands in for
+type abstractDigraph struct {
+	vertices map[Vertex]bool
+	edges    map[Vertex][]Edge
+	weights  map[Edge]float32
+	paths    map[Edge]Path
+}
+
+func (ad *abstractDigraph) Vertices() []Vertex {
+	verts := make([]Vertex, 0, len(ad.vertices))
+	for v := range ad.vertices {
+		verts = append(verts, v)
+	}
+	return verts
+}
+
+func (ad *abstractDigraph) Edges() map[Vertex][]Edge {
+	return ad.edges
+}
+
+func (ad *abstractDigraph) Weights() map[Edge]float32 {
+	return ad.weights
+}
+
+func (ad *abstractDigraph) AddEdge(from, to Vertex) {
+	panic("cannot add edges to an abstract graph directly")
+}
+
+func (ad *abstractDigraph) RemoveEdge(Edge) {
+	panic("cannot remove edges from an abstract graph directly")
+}
+
+func (ad *abstractDigraph) addPath(p Path) Edge {
+	from, to := p.Vertices[0], p.Vertices[len(p.Vertices)-1]
+	e := NewEdge(from, to)
+	ad.edges[from] = append(ad.edges[from], e)
+	ad.weights[e] = p.Cost
+	ad.paths[e] = p
+	return e
+}
+
+func (ad *abstractDigraph) removeEdges(edges []Edge) {
+	remove := make(map[Edge]bool)
+	for _, e := range edges {
+		remove[e] = true
+	}
+
+	for _, e := range edges {
+		es := ad.edges[e.From()]
+		kept := es[:0]
+		for _, e1 := range es {
+			if !remove[e1] {
+				kept = append(kept, e1)
+			}
+		}
+		ad.edges[e.From()] = kept
+		delete(ad.weights, e)
+		delete(ad.paths, e)
+	}
+}
+
+// HPAGraph is a hierarchical pathfinding (HPA*) abstraction of a weighted digraph.
+// The graph is split into clusters, and every vertex with an edge crossing
+// between clusters is an entrance. The abstract graph joins entrances with the
+// edges crossing between clusters, and with the precomputed cost of the best path
+// inside a cluster between each pair of its entrances. Queries search the much
+// smaller abstract graph and then refine the result into a concrete path.
+//
+// Queries temporarily insert their endpoints into the abstract graph, so an
+// HPAGraph must not be queried from several goroutines at once.
+type HPAGraph struct {
+	graph     WeightedDigraph
+	partition map[Vertex]int
+	clusters  map[int][]Vertex
+	entrances map[int][]Vertex
+	crossing  map[int][]Edge // edges leaving each cluster, in the abstract graph
+	incoming  map[int][]Edge // edges entering each cluster, in the abstract graph
+	intra     map[int][]Edge // abstract edges between entrances of the same cluster
+	fromEntry map[Vertex]RelaxableAttributes
+	abstract  *abstractDigraph
+}
+
+// NewHPAGraph builds the abstract graph for graph using the given partition of its
+// vertices into clusters, such as one made by GridPartition or BFSPartition
+func NewHPAGraph(graph WeightedDigraph, partition map[Vertex]int) *HPAGraph {
+	h := HPAGraph{
+		graph:     graph,
+		partition: partition,
+		clusters:  make(map[int][]Vertex),
+		entrances: make(map[int][]Vertex),
+		crossing:  make(map[int][]Edge),
+		incoming:  make(map[int][]Edge),
+		intra:     make(map[int][]Edge),
+		fromEntry: make(map[Vertex]RelaxableAttributes),
+		abstract: &abstractDigraph{
+			vertices: make(map[Vertex]bool),
+			edges:    make(map[Vertex][]Edge),
+			weights:  make(map[Edge]float32),
+			paths:    make(map[Edge]Path),
+		},
+	}
+
+	for _, v := range graph.Vertices() {
+		c, ok := partition[v]
+		if !ok {
+			panic("vertex not in partition")
+		}
+		h.clusters[c] = append(h.clusters[c], v)
+	}
+
+	for c := range h.clusters {
+		h.buildCrossing(c)
+	}
+	for c := range h.clusters {
+		h.buildEntrances(c)
+	}
+
+	return &h
+}
+
+// Abstract returns the abstract graph of entrances
+func (h *HPAGraph) Abstract() WeightedDigraph {
+	return h.abstract
+}
+
+// Entrances returns the entrance vertices of a cluster
+func (h *HPAGraph) Entrances(cluster int) []Vertex {
+	return h.entrances[cluster]
+}
+
+// Cluster returns the id of the cluster that v belongs to
+func (h *HPAGraph) Cluster(v Vertex) int {
+	return h.partition[v]
+}
+
+// buildCrossing finds the edges leaving cluster c and adds them to the abstract graph
+func (h *HPAGraph) buildCrossing(c int) {
+	edges := h.graph.Edges()
+	weights := h.graph.Weights()
+	for _, v := range h.clusters[c] {
+		for _, e := range edges[v] {
+			if to := h.partition[e.To()]; to != c {
+				ae := h.abstract.addPath(Path{
+					Vertices: []Vertex{v, e.To()},
+					Edges:    []Edge{e},
+					Cost:     weights[e],
+				})
+				h.crossing[c] = append(h.crossing[c], ae)
+				h.incoming[to] = append(h.incoming[to], ae)
+			}
+		}
+	}
+}
+
+// buildEntrances works out which vertices of cluster c are entrances, and joins each
+// pair of them in the abstract graph with the shortest path inside the cluster
+func (h *HPAGraph) buildEntrances(c int) {
+	h.abstract.removeEdges(h.intra[c])
+	h.intra[c] = nil
+	for _, v := range h.entrances[c] {
+		delete(h.abstract.vertices, v)
+		delete(h.fromEntry, v)
+	}
+
+	isEntrance := make(map[Vertex]bool)
+	for _, e := range h.crossing[c] {
+		isEntrance[e.From()] = true
+	}
+	for _, e := range h.incoming[c] {
+		isEntrance[e.To()] = true
+	}
+	entrances := make([]Vertex, 0, len(isEntrance))
+	for _, v := range h.clusters[c] {
+		if isEntrance[v] {
+			entrances = append(entrances, v)
+			h.abstract.vertices[v] = true
+		}
+	}
+	h.entrances[c] = entrances
+
+	cluster := newInducedDigraph(h.graph, h.clusters[c])
+	for _, from := range entrances {
+		attrs := Dijkstra(cluster, from)
+		h.fromEntry[from] = attrs
+		for _, to := range entrances {
+			if to == from {
+				continue
+			}
+			if p, ok := PathFromAttributes(cluster, attrs, from, to); ok {
+				h.intra[c] = append(h.intra[c], h.abstract.addPath(p))
+			}
+		}
+	}
+}
+
+// RebuildCluster recalculates a cluster's part of the abstract graph after edges
+// inside it, or leaving it, have been added, removed or reweighted. A changed edge
+// between two clusters is owned by the cluster it leaves.
+func (h *HPAGraph) RebuildCluster(c int) {
+	affected := map[int]bool{c: true}
+
+	old := h.crossing[c]
+	h.abstract.removeEdges(old)
+	for _, e := range old {
+		to := h.partition[e.To()]
+		affected[to] = true
+		h.incoming[to] = removeFromEdges(h.incoming[to], e)
+	}
+	h.crossing[c] = nil
+
+	h.buildCrossing(c)
+	for _, e := range h.crossing[c] {
+		affected[h.partition[e.To()]] = true
+	}
+
+	for a := range affected {
+		if a == c || !sameVertices(h.entrances[a], h.entranceSet(a)) {
+			h.buildEntrances(a)
+		}
+	}
+}
+
+func (h *HPAGraph) entranceSet(c int) map[Vertex]bool {
+	set := make(map[Vertex]bool)
+	for _, e := range h.crossing[c] {
+		set[e.From()] = true
+	}
+	for _, e := range h.incoming[c] {
+		set[e.To()] = true
+	}
+	return set
+}
+
+func sameVertices(verts []Vertex, set map[Vertex]bool) bool {
+	if len(verts) != len(set) {
+		return false
+	}
+	for _, v := range verts {
+		if !set[v] {
+			return false
+		}
+	}
+	return true
+}
+
+func removeFromEdges(edges []Edge, edge Edge) []Edge {
+	for i, e := range edges {
+		if e == edge {
+			return append(edges[:i:i], edges[i+1:]...)
+		}
+	}
+	return edges
+}
+
+// AbstractPath finds the shortest path between source and destination through the
+// abstract graph, visiting only the entrances along the way
+func (h *HPAGraph) AbstractPath(source, destination Vertex) (Path, bool) {
+	abstractPath, _, ok := h.search(source, destination)
+	return abstractPath, ok
+}
+
+// Path finds a path from source to destination by searching the abstract graph and
+// refining the result into a concrete path
+func (h *HPAGraph) Path(source, destination Vertex) (Path, bool) {
+	_, path, ok := h.search(source, destination)
+	return path, ok
+}
+
+func (h *HPAGraph) search(source, destination Vertex) (abstractPath, path Path, ok bool) {
+	sc, dc := h.partition[source], h.partition[destination]
+
+	// temporarily connect the source and destination to the entrances of their clusters
+	temporary := make([]Edge, 0)
+	addedVerts := make([]Vertex, 0, 2)
+	for _, v := range []Vertex{source, destination} {
+		if !h.abstract.vertices[v] {
+			h.abstract.vertices[v] = true
+			addedVerts = append(addedVerts, v)
+		}
+	}
+
+	sourceCluster := newInducedDigraph(h.graph, h.clusters[sc])
+	fromSource := Dijkstra(sourceCluster, source)
+	if _, ok := h.fromEntry[source]; !ok {
+		for _, entrance := range h.entrances[sc] {
+			if p, ok := PathFromAttributes(sourceCluster, fromSource, source, entrance); ok {
+				temporary = append(temporary, h.abstract.addPath(p))
+			}
+		}
+	}
+	if _, ok := h.fromEntry[destination]; !ok {
+		destCluster := newInducedDigraph(h.graph, h.clusters[dc])
+		for _, entrance := range h.entrances[dc] {
+			if p, ok := PathFromAttributes(destCluster, h.fromEntry[entrance], entrance, destination); ok {
+				temporary = append(temporary, h.abstract.addPath(p))
+			}
+		}
+	}
+	if sc == dc {
+		if p, ok := PathFromAttributes(sourceCluster, fromSource, source, destination); ok && p.Len() > 0 {
+			temporary = append(temporary, h.abstract.addPath(p))
+		}
+	}
+
+	abstractPath, ok = PathFromAttributes(h.abstract, Dijkstra(h.abstract, source), source, destination)
+	if ok {
+		// refine before the temporary edges are dropped, as they hold the concrete paths
+		path = h.refine(abstractPath)
+	}
+
+	h.abstract.removeEdges(temporary)
+	for _, v := range addedVerts {
+		delete(h.abstract.vertices, v)
+	}
+
+	return abstractPath, path, ok
+}
+
+// refine turns a path through the abstract graph into one through the concrete graph
+func (h *HPAGraph) refine(abstractPath Path) Path {
+	p := Path{
+		Vertices: make([]Vertex, 0, len(abstractPath.Vertices)),
+		Edges:    make([]Edge, 0, len(abstractPath.Edges)),
+	}
+	if len(abstractPath.Vertices) > 0 {
+		p.Vertices = append(p.Vertices, abstractPath.Vertices[0])
+	}
+
+	for _, ae := range abstractPath.Edges {
+		concrete := h.abstract.paths[ae]
+		p.Edges = append(p.Edges, concrete.Edges...)
+		p.Vertices = append(p.Vertices, concrete.Vertices[1:]...)
+		p.Cost += concrete.Cost
+	}
+
+	return p
+}
@@ -0,0 +1,155 @@
+package graph
+
+import "github.com/DaJobat/gogve/unionfind"
+
+// IncrementalComponents wraps a graph and keeps track of its connected components,
+// treating the edges as undirected, as edges are added through it. Adding an edge is a
+// single union-find union, so answering connectivity as the graph grows costs almost
+// nothing. A union-find can't split a set, so removing an edge through it means the
+// components are worked out again from scratch on the next query
+type IncrementalComponents struct {
+	graph       DirectedGraph
+	sets        *unionfind.UnionFind
+	index       map[Vertex]int
+	vertices    []Vertex
+	stale       bool
+	unsubscribe func() // set when following an ObservableGraph
+}
+
+// NewIncrementalComponents finds the components of graph, which should afterwards only
+// be changed through the returned wrapper
+func NewIncrementalComponents(graph DirectedGraph) *IncrementalComponents {
+	ic := IncrementalComponents{graph: graph}
+	ic.rebuild()
+	return &ic
+}
+
+// ObserveComponents keeps track of the components of og however it is changed, by
+// subscribing to its changes. Close stops it
+func ObserveComponents(og *ObservableGraph) *IncrementalComponents {
+	ic := IncrementalComponents{graph: og}
+	ic.rebuild()
+	ic.unsubscribe = og.Subscribe(ic.observe)
+	return &ic
+}
+
+func (ic *IncrementalComponents) observe(c Change) {
+	switch c.Kind {
+	case VertexAdded:
+		if !ic.stale {
+			ic.id(c.Vertex)
+		}
+	case EdgeAdded:
+		if !ic.stale {
+			ic.sets.Union(ic.id(c.Edge.From()), ic.id(c.Edge.To()))
+		}
+	case EdgeRemoved, VertexRemoved:
+		ic.stale = true
+	}
+}
+
+// Close stops following the ObservableGraph, if there is one
+func (ic *IncrementalComponents) Close() {
+	if ic.unsubscribe != nil {
+		ic.unsubscribe()
+		ic.unsubscribe = nil
+		ic.stale = true
+	}
+}
+
+func (ic *IncrementalComponents) rebuild() {
+	ic.sets = unionfind.New(0)
+	ic.index = make(map[Vertex]int)
+	ic.vertices = make([]Vertex, 0)
+	for _, v := range ic.graph.Vertices() {
+		ic.id(v)
+	}
+	for v, es := range ic.graph.Edges() {
+		for _, e := range es {
+			ic.sets.Union(ic.id(v), ic.id(e.To()))
+		}
+	}
+	ic.stale = false
+}
+
+// id returns the union-find element of v, adding one if v is new
+func (ic *IncrementalComponents) id(v Vertex) int {
+	if id, ok := ic.index[v]; ok {
+		return id
+	}
+	id := ic.sets.Add()
+	ic.index[v] = id
+	ic.vertices = append(ic.vertices, v)
+	return id
+}
+
+func (ic *IncrementalComponents) refresh() {
+	if ic.stale {
+		ic.rebuild()
+	}
+}
+
+func (ic *IncrementalComponents) Vertices() []Vertex {
+	return ic.graph.Vertices()
+}
+
+func (ic *IncrementalComponents) Edges() map[Vertex][]Edge {
+	return ic.graph.Edges()
+}
+
+// AddEdge adds the edge to the underlying graph and joins the components of its ends
+func (ic *IncrementalComponents) AddEdge(from, to Vertex) {
+	ic.graph.AddEdge(from, to)
+	if ic.unsubscribe == nil && !ic.stale {
+		ic.sets.Union(ic.id(from), ic.id(to))
+	}
+}
+
+// RemoveEdge removes the edge from the underlying graph, the components are found
+// again on the next query
+func (ic *IncrementalComponents) RemoveEdge(edge Edge) {
+	ic.graph.RemoveEdge(edge)
+	ic.stale = true
+}
+
+// Connected returns true if a and b are in the same component. A vertex the graph has
+// never had is in a component of its own
+func (ic *IncrementalComponents) Connected(a, b Vertex) bool {
+	if a == b {
+		return true
+	}
+	ic.refresh()
+	ia, aok := ic.index[a]
+	ib, bok := ic.index[b]
+	return aok && bok && ic.sets.Connected(ia, ib)
+}
+
+// Count returns the number of components
+func (ic *IncrementalComponents) Count() int {
+	ic.refresh()
+	return ic.sets.Count()
+}
+
+// Size returns the number of vertices in v's component
+func (ic *IncrementalComponents) Size(v Vertex) int {
+	ic.refresh()
+	id, ok := ic.index[v]
+	if !ok {
+		return 1
+	}
+	return ic.sets.Size(id)
+}
+
+// Components returns the vertices of every component
+func (ic *IncrementalComponents) Components() [][]Vertex {
+	ic.refresh()
+	components := make([][]Vertex, 0, ic.sets.Count())
+	for _, set := range ic.sets.Sets() {
+		component := make([]Vertex, len(set))
+		for i, id := range set {
+			component[i] = ic.vertices[id]
+		}
+		components = append(components, component)
+	}
+	return components
+}
@@ -0,0 +1,416 @@
+package graph
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+)
+
+// VertexMatch says whether a vertex of the first graph may be mapped onto one of the second
+type VertexMatch func(from, to Vertex) bool
+
+// EdgeMatch says whether an edge of the first graph may be mapped onto one of the second
+type EdgeMatch func(from, to Edge) bool
+
+// Mapping maps the vertices of one graph onto another
+type Mapping map[Vertex]Vertex
+
+// Isomorphism uses VF2 to find a mapping of g1's vertices onto g2's that takes every
+// edge of g1 to an edge of g2 and back. Either predicate may be nil to match anything.
+// Returns false if the graphs aren't isomorphic
+func Isomorphism(g1, g2 DirectedGraph, vm VertexMatch, em EdgeMatch) (Mapping, bool) {
+	var found Mapping
+	vf2(g1, g2, vm, em, false, func(m Mapping) bool {
+		found = m
+		return false
+	})
+	return found, found != nil
+}
+
+// Isomorphisms is Isomorphism returning every mapping, so for automorphisms
+// (g1 and g2 the same graph) it gives all of the graph's symmetries
+func Isomorphisms(g1, g2 DirectedGraph, vm VertexMatch, em EdgeMatch) []Mapping {
+	found := make([]Mapping, 0)
+	vf2(g1, g2, vm, em, false, func(m Mapping) bool {
+		found = append(found, m)
+		return true
+	})
+	return found
+}
+
+// SubgraphIsomorphism uses VF2 to find a mapping of pattern's vertices onto some of
+// graph's, such that the subgraph of graph induced by those vertices is isomorphic to
+// pattern: every edge of pattern is there and no others. Returns false if there is none
+func SubgraphIsomorphism(pattern, graph DirectedGraph, vm VertexMatch, em EdgeMatch) (Mapping, bool) {
+	var found Mapping
+	vf2(pattern, graph, vm, em, true, func(m Mapping) bool {
+		found = m
+		return false
+	})
+	return found, found != nil
+}
+
+// SubgraphIsomorphisms is SubgraphIsomorphism returning every mapping. A symmetric
+// pattern matches the same vertices once for each of its automorphisms
+func SubgraphIsomorphisms(pattern, graph DirectedGraph, vm VertexMatch, em EdgeMatch) []Mapping {
+	found := make([]Mapping, 0)
+	vf2(pattern, graph, vm, em, true, func(m Mapping) bool {
+		found = append(found, m)
+		return true
+	})
+	return found
+}
+
+// vf2Graph is one side of a VF2 match, with its vertices numbered
+// and the edges between each pair of them grouped together
+type vf2Graph struct {
+	vertices []Vertex
+	succ     []map[int][]Edge
+	pred     []map[int][]Edge
+	core     []int // the vertex of the other graph this one is mapped to, or -1
+	in       []int // the depth a vertex joined the in terminal set, or 0
+	out      []int // the depth a vertex joined the out terminal set, or 0
+}
+
+func newVF2Graph(graph DirectedGraph) *vf2Graph {
+	g := vf2Graph{
+		vertices: graph.Vertices(),
+	}
+	n := len(g.vertices)
+	index := make(map[Vertex]int, n)
+	for i, v := range g.vertices {
+		index[v] = i
+	}
+	g.succ = make([]map[int][]Edge, n)
+	g.pred = make([]map[int][]Edge, n)
+	for i := range g.vertices {
+		g.succ[i] = make(map[int][]Edge)
+		g.pred[i] = make(map[int][]Edge)
+	}
+	for i, v := range g.vertices {
+		for _, e := range graph.Edges()[v] {
+			j := index[e.To()]
+			g.succ[i][j] = append(g.succ[i][j], e)
+			g.pred[j][i] = append(g.pred[j][i], e)
+		}
+	}
+
+	g.core = make([]int, n)
+	for i := range g.core {
+		g.core[i] = -1
+	}
+	g.in = make([]int, n)
+	g.out = make([]int, n)
+	return &g
+}
+
+// add maps v and grows the terminal sets with its unmapped neighbours
+func (g *vf2Graph) add(v, to, depth int) {
+	g.core[v] = to
+	if g.in[v] == 0 {
+		g.in[v] = depth
+	}
+	if g.out[v] == 0 {
+		g.out[v] = depth
+	}
+	for u := range g.pred[v] {
+		if g.in[u] == 0 {
+			g.in[u] = depth
+		}
+	}
+	for u := range g.succ[v] {
+		if g.out[u] == 0 {
+			g.out[u] = depth
+		}
+	}
+}
+
+// remove undoes add, taking out everything added at depth
+func (g *vf2Graph) remove(v, depth int) {
+	g.core[v] = -1
+	for u := range g.core {
+		if g.in[u] == depth {
+			g.in[u] = 0
+		}
+		if g.out[u] == depth {
+			g.out[u] = 0
+		}
+	}
+}
+
+// terminal returns the unmapped vertices in the given terminal set
+func (g *vf2Graph) terminal(set []int) []int {
+	verts := make([]int, 0)
+	for v, d := range set {
+		if d > 0 && g.core[v] < 0 {
+			verts = append(verts, v)
+		}
+	}
+	return verts
+}
+
+// lookahead counts the unmapped vertices of neighbors in the in and out terminal sets and outside both
+func (g *vf2Graph) lookahead(neighbors map[int][]Edge) (in, out, rest int) {
+	for u := range neighbors {
+		if g.core[u] >= 0 {
+			continue
+		}
+		if g.in[u] > 0 {
+			in++
+		}
+		if g.out[u] > 0 {
+			out++
+		}
+		if g.in[u] == 0 && g.out[u] == 0 {
+			rest++
+		}
+	}
+	return in, out, rest
+}
+
+type vf2State struct {
+	small, large *vf2Graph
+	vm           VertexMatch
+	em           EdgeMatch
+	subgraph     bool
+	depth        int
+}
+
+// vf2 maps the vertices of small onto large, calling found with each complete mapping
+// until it returns false. If subgraph is false the two must be the same size
+func vf2(small, large DirectedGraph, vm VertexMatch, em EdgeMatch, subgraph bool, found func(Mapping) bool) {
+	s := vf2State{
+		small:    newVF2Graph(small),
+		large:    newVF2Graph(large),
+		vm:       vm,
+		em:       em,
+		subgraph: subgraph,
+	}
+	if len(s.small.vertices) > len(s.large.vertices) ||
+		(!subgraph && len(s.small.vertices) != len(s.large.vertices)) {
+		return
+	}
+	if !subgraph && edgeCount(small) != edgeCount(large) {
+		return
+	}
+	s.match(found)
+}
+
+func edgeCount(graph DirectedGraph) int {
+	n := 0
+	for _, es := range graph.Edges() {
+		n += len(es)
+	}
+	return n
+}
+
+// match extends the current partial mapping, returning false once found asks to stop
+func (s *vf2State) match(found func(Mapping) bool) bool {
+	if s.depth == len(s.small.vertices) {
+		m := make(Mapping, s.depth)
+		for v, to := range s.small.core {
+			m[s.small.vertices[v]] = s.large.vertices[to]
+		}
+		return found(m)
+	}
+
+	// the next small vertex is the lowest numbered unmapped one in the out terminal
+	// set, failing that the in terminal set, failing that the lowest unmapped vertex.
+	// The large vertices it could map to come from the same set
+	smallOut, largeOut := s.small.terminal(s.small.out), s.large.terminal(s.large.out)
+	smallIn, largeIn := s.small.terminal(s.small.in), s.large.terminal(s.large.in)
+	var v int
+	var candidates []int
+	switch {
+	case len(smallOut) > 0:
+		v, candidates = smallOut[0], largeOut
+	case len(smallIn) > 0:
+		if !s.subgraph && len(largeOut) > 0 {
+			return true
+		}
+		v, candidates = smallIn[0], largeIn
+	default:
+		if !s.subgraph && (len(largeOut) > 0 || len(largeIn) > 0) {
+			return true
+		}
+		for i, to := range s.small.core {
+			if to < 0 {
+				v = i
+				break
+			}
+		}
+		candidates = make([]int, 0)
+		for i, to := range s.large.core {
+			if to < 0 {
+				candidates = append(candidates, i)
+			}
+		}
+	}
+
+	for _, w := range candidates {
+		if !s.feasible(v, w) {
+			continue
+		}
+		s.depth++
+		s.small.add(v, w, s.depth)
+		s.large.add(w, v, s.depth)
+		more := s.match(found)
+		s.small.remove(v, s.depth)
+		s.large.remove(w, s.depth)
+		s.depth--
+		if !more {
+			return false
+		}
+	}
+	return true
+}
+
+// feasible checks whether small vertex v can be mapped onto large vertex w
+func (s *vf2State) feasible(v, w int) bool {
+	if s.vm != nil && !s.vm(s.small.vertices[v], s.large.vertices[w]) {
+		return false
+	}
+
+	// edges to and from the mapped vertices, and loops, must correspond
+	if !s.edgesMatch(s.small.succ[v][v], s.large.succ[w][w]) {
+		return false
+	}
+	for _, dir := range [2]bool{true, false} {
+		smallN, largeN := s.small.succ[v], s.large.succ[w]
+		if !dir {
+			smallN, largeN = s.small.pred[v], s.large.pred[w]
+		}
+		for u, es := range smallN {
+			if to := s.small.core[u]; to >= 0 && !s.edgesMatch(es, largeN[to]) {
+				return false
+			}
+		}
+		for u, es := range largeN {
+			to := s.large.core[u]
+			if to < 0 {
+				continue
+			}
+			if _, ok := smallN[to]; !ok && len(es) > 0 {
+				return false
+			}
+		}
+	}
+
+	// look ahead to prune mappings that can't be completed, for a subgraph the large
+	// graph only needs at least as many neighbours
+	ok := func(small, large int) bool {
+		if s.subgraph {
+			return small <= large
+		}
+		return small == large
+	}
+	for _, dir := range [2]bool{true, false} {
+		smallN, largeN := s.small.succ[v], s.large.succ[w]
+		if !dir {
+			smallN, largeN = s.small.pred[v], s.large.pred[w]
+		}
+		si, so, sr := s.small.lookahead(smallN)
+		li, lo, lr := s.large.lookahead(largeN)
+		if !ok(si, li) || !ok(so, lo) || !ok(sr, lr) {
+			return false
+		}
+	}
+	return true
+}
+
+// edgesMatch pairs off the parallel edges between two vertices of the small graph with
+// those between their images in the large graph, which must be the same in number
+func (s *vf2State) edgesMatch(small, large []Edge) bool {
+	if len(small) != len(large) {
+		return false
+	}
+	if s.em == nil {
+		return true
+	}
+	used := make([]bool, len(large))
+	for _, se := range small {
+		matched := false
+		for i, le := range large {
+			if !used[i] && s.em(se, le) {
+				used[i] = true
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// WeisfeilerLehmanHash hashes the structure of the graph by repeatedly relabelling each
+// vertex with a hash of its own label and the sorted labels of its successors and its
+// predecessors, then hashing the counts of every label seen. Isomorphic graphs always
+// hash the same, so different hashes prove graphs aren't isomorphic, though some
+// non-isomorphic graphs do share a hash. label gives each vertex's starting label, and
+// may be nil to start them all the same
+func WeisfeilerLehmanHash(graph DirectedGraph, iterations int, label func(Vertex) string) uint64 {
+	verts := graph.Vertices()
+	labels := make(map[Vertex]uint64, len(verts))
+	for _, v := range verts {
+		h := fnv.New64a()
+		if label != nil {
+			h.Write([]byte(label(v)))
+		}
+		labels[v] = h.Sum64()
+	}
+
+	preds := make(map[Vertex][]Vertex)
+	for _, es := range graph.Edges() {
+		for _, e := range es {
+			preds[e.To()] = append(preds[e.To()], e.From())
+		}
+	}
+
+	seen := make([]uint64, 0, len(verts)*(iterations+1))
+	for _, l := range labels {
+		seen = append(seen, l)
+	}
+	for i := 0; i < iterations; i++ {
+		next := make(map[Vertex]uint64, len(verts))
+		for _, v := range verts {
+			out := make([]uint64, 0, len(graph.Edges()[v]))
+			for _, e := range graph.Edges()[v] {
+				out = append(out, labels[e.To()])
+			}
+			in := make([]uint64, 0, len(preds[v]))
+			for _, u := range preds[v] {
+				in = append(in, labels[u])
+			}
+			next[v] = hashLabels(labels[v], out, in)
+		}
+		labels = next
+		for _, l := range labels {
+			seen = append(seen, l)
+		}
+	}
+
+	return hashLabels(uint64(len(verts)), seen)
+}
+
+// hashLabels hashes first followed by each list of labels, sorted so their order doesn't matter
+func hashLabels(first uint64, lists ...[]uint64) uint64 {
+	h := fnv.New64a()
+	buf := make([]byte, 8)
+	binary.LittleEndian.PutUint64(buf, first)
+	h.Write(buf)
+	for _, list := range lists {
+		sort.Slice(list, func(i, j int) bool {
+			return list[i] < list[j]
+		})
+		// separate the lists so labels can't move between them
+		binary.LittleEndian.PutUint64(buf, uint64(len(list)))
+		h.Write(buf)
+		for _, l := range list {
+			binary.LittleEndian.PutUint64(buf, l)
+			h.Write(buf)
+		}
+	}
+	return h.Sum64()
+}
@@ -0,0 +1,313 @@
+package graph
+
+import (
+	"math"
+
+	"github.com/DaJobat/gogve/priorityqueue"
+)
+
+// maskedDigraph hides a set of vertices and edges of a weighted digraph. The edge
+// map is computed once at creation, so the mask must not change afterwards
+type maskedDigraph struct {
+	WeightedDigraph
+	vertices []Vertex
+	edges    map[Vertex][]Edge
+}
+
+func newMaskedDigraph(graph WeightedDigraph, vertices map[Vertex]bool, edges map[Edge]bool) *maskedDigraph {
+	md := maskedDigraph{
+		WeightedDigraph: graph,
+		vertices:        make([]Vertex, 0, len(graph.Vertices())),
+		edges:           make(map[Vertex][]Edge),
+	}
+
+	for _, v := range graph.Vertices() {
+		if !vertices[v] {
+			md.vertices = append(md.vertices, v)
+		}
+	}
+
+	for v, es := range graph.Edges() {
+		if vertices[v] {
+			continue
+		}
+		kept := make([]Edge, 0, len(es))
+		for _, e := range es {
+			if !edges[e] && !vertices[e.To()] {
+				kept = append(kept, e)
+			}
+		}
+		md.edges[v] = kept
+	}
+
+	return &md
+}
+
+func (md *maskedDigraph) Vertices() []Vertex {
+	return md.vertices
+}
+
+func (md *maskedDigraph) Edges() map[Vertex][]Edge {
+	return md.edges
+}
+
+// reversedDigraph is a copy of a weighted digraph with every edge flipped
+type reversedDigraph struct {
+	vertices []Vertex
+	edges    map[Vertex][]Edge
+	weights  map[Edge]float32
+}
+
+func newReversedDigraph(graph WeightedDigraph) *reversedDigraph {
+	rd := reversedDigraph{
+		vertices: graph.Vertices(),
+		edges:    make(map[Vertex][]Edge),
+		weights:  make(map[Edge]float32),
+	}
+
+	weights := graph.Weights()
+	for _, es := range graph.Edges() {
+		for _, e := range es {
+			re := NewEdge(e.To(), e.From())
+			rd.edges[re.From()] = append(rd.edges[re.From()], re)
+			rd.weights[re] = weights[e]
+		}
+	}
+
+	return &rd
+}
+
+func (rd *reversedDigraph) Vertices() []Vertex {
+	return rd.vertices
+}
+
+func (rd *reversedDigraph) Edges() map[Vertex][]Edge {
+	return rd.edges
+}
+
+func (rd *reversedDigraph) Weights() map[Edge]float32 {
+	return rd.weights
+}
+
+func (rd *reversedDigraph) AddEdge(from, to Vertex) {
+	panic("cannot add edges to a reversed digraph")
+}
+
+func (rd *reversedDigraph) RemoveEdge(Edge) {
+	panic("cannot remove edges from a reversed digraph")
+}
+
+// KShortestPaths uses Yen's algorithm to find up to k loopless paths from source to
+// destination, in order of increasing cost. Fewer than k paths are returned if the
+// graph doesn't contain that many
+func KShortestPaths(graph WeightedDigraph, source, destination Vertex, k int) []Path {
+	if k <= 0 {
+		return nil
+	}
+
+	first, ok := PathFromAttributes(graph, Dijkstra(graph, source), source, destination)
+	if !ok {
+		return nil
+	}
+
+	found := []Path{first}
+	candidates := make([]Path, 0)
+
+	for len(found) < k {
+		previous := found[len(found)-1]
+		for i := 0; i < previous.Len(); i++ {
+			// the spur vertex is where we deviate from the previous path,
+			// everything before it is the root path which we keep the same
+			spur := previous.Vertices[i]
+			rootEdges := previous.Edges[:i]
+
+			removedEdges := make(map[Edge]bool)
+			for _, p := range found {
+				if p.Len() > i && edgesEqual(p.Edges[:i], rootEdges) {
+					removedEdges[p.Edges[i]] = true
+				}
+			}
+			removedVerts := make(map[Vertex]bool)
+			for _, v := range previous.Vertices[:i] {
+				removedVerts[v] = true
+			}
+
+			masked := newMaskedDigraph(graph, removedVerts, removedEdges)
+			spurPath, ok := PathFromAttributes(masked, Dijkstra(masked, spur), spur, destination)
+			if !ok {
+				continue
+			}
+
+			candidate := joinPaths(previous.Vertices[:i+1], rootEdges, graph.Weights(), spurPath)
+			if !containsPath(candidates, candidate) && !containsPath(found, candidate) {
+				candidates = append(candidates, candidate)
+			}
+		}
+
+		if len(candidates) == 0 {
+			break
+		}
+
+		best := 0
+		for i := range candidates {
+			if candidates[i].Cost < candidates[best].Cost {
+				best = i
+			}
+		}
+		found = append(found, candidates[best])
+		candidates = append(candidates[:best], candidates[best+1:]...)
+	}
+
+	return found
+}
+
+// joinPaths prepends a root path (ending at the spur vertex) to a spur path
+func joinPaths(rootVerts []Vertex, rootEdges []Edge, weights map[Edge]float32, spur Path) Path {
+	p := Path{
+		Vertices: make([]Vertex, 0, len(rootVerts)+len(spur.Vertices)-1),
+		Edges:    make([]Edge, 0, len(rootEdges)+len(spur.Edges)),
+		Cost:     spur.Cost,
+	}
+	p.Vertices = append(append(p.Vertices, rootVerts...), spur.Vertices[1:]...)
+	p.Edges = append(append(p.Edges, rootEdges...), spur.Edges...)
+	for _, e := range rootEdges {
+		p.Cost += weights[e]
+	}
+	return p
+}
+
+func edgesEqual(a, b []Edge) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsPath(paths []Path, p Path) bool {
+	for _, q := range paths {
+		if edgesEqual(q.Edges, p.Edges) {
+			return true
+		}
+	}
+	return false
+}
+
+// sidetrackCandidate is a walk in the Eppstein enumeration, stored as the list of
+// edges taken that leave the shortest path tree
+type sidetrackCandidate struct {
+	sidetracks []Edge
+	cost       float32
+}
+
+// KShortestWalks finds the k cheapest walks from source to destination where
+// vertices (including the destination) may be repeated, in order of increasing cost.
+// It follows Eppstein's approach: a shortest path tree towards the destination
+// is built, and every other walk is described by the sequence of "sidetrack"
+// edges it takes off that tree, which are enumerated best first.
+func KShortestWalks(graph WeightedDigraph, source, destination Vertex, k int) []Path {
+	if k <= 0 {
+		return nil
+	}
+
+	// distances to the destination come from running dijkstra on the reverse graph
+	reversed := newReversedDigraph(graph)
+	toDest := Dijkstra(reversed, destination)
+	dist := func(v Vertex) float32 {
+		return toDest[v].ShortestEstimateFromSource()
+	}
+	if math.IsInf(float64(dist(source)), 1) {
+		return nil
+	}
+
+	// treeEdge is the next edge on the shortest path from a vertex to the destination
+	treeEdge := make(map[Vertex]Edge)
+	for v, attr := range toDest {
+		if v == destination || attr.Predecessor() == nil {
+			continue
+		}
+		if p, ok := pathFromVertices(graph, []Vertex{v, attr.Predecessor()}); ok {
+			treeEdge[v] = p.Edges[0]
+		}
+	}
+
+	// sidetrack edges are all the reachable edges not in the tree, costing
+	// how much more it is to take them than to stay on the tree
+	weights := graph.Weights()
+	sidetracks := make(map[Vertex][]Edge)
+	delta := make(map[Edge]float32)
+	for v, es := range graph.Edges() {
+		if math.IsInf(float64(dist(v)), 1) {
+			continue
+		}
+		for _, e := range es {
+			if e == treeEdge[v] || math.IsInf(float64(dist(e.To())), 1) {
+				continue
+			}
+			sidetracks[v] = append(sidetracks[v], e)
+			delta[e] = weights[e] + dist(e.To()) - dist(v)
+		}
+	}
+
+	treePath := func(from Vertex) []Vertex {
+		verts := []Vertex{from}
+		for from != destination {
+			from = treeEdge[from].To()
+			verts = append(verts, from)
+		}
+		return verts
+	}
+
+	walks := make([]Path, 0, k)
+	// candidates are keyed by pointer, so each is queued separately even at equal cost
+	queue := priorityqueue.NewBinaryHeap(priorityqueue.MinFirst)
+	queue.Push(&sidetrackCandidate{cost: dist(source)}, float64(dist(source)))
+	for queue.Len() > 0 && len(walks) < k {
+		key, _ := queue.Pop()
+		current := key.(*sidetrackCandidate)
+
+		walk := Path{
+			Vertices: []Vertex{source},
+			Edges:    make([]Edge, 0),
+		}
+		take := func(e Edge) {
+			walk.Edges = append(walk.Edges, e)
+			walk.Vertices = append(walk.Vertices, e.To())
+			walk.Cost += weights[e]
+		}
+		at := source
+		for _, st := range current.sidetracks {
+			for at != st.From() {
+				take(treeEdge[at])
+				at = treeEdge[at].To()
+			}
+			take(st)
+			at = st.To()
+		}
+		start := at
+		for at != destination {
+			take(treeEdge[at])
+			at = treeEdge[at].To()
+		}
+		walks = append(walks, walk)
+
+		// extend this walk with a further sidetrack anywhere along its final stretch of tree path
+		for _, v := range treePath(start) {
+			for _, st := range sidetracks[v] {
+				next := &sidetrackCandidate{
+					sidetracks: make([]Edge, len(current.sidetracks)+1),
+					cost:       current.cost + delta[st],
+				}
+				copy(next.sidetracks, current.sidetracks)
+				next.sidetracks[len(current.sidetracks)] = st
+				queue.Push(next, float64(next.cost))
+			}
+		}
+	}
+
+	return walks
+}
@@ -0,0 +1,160 @@
+package graph
+
+import (
+	"container/list"
+	"math"
+	"sort"
+)
+
+// initMultiSource is initSingleSource for several sources at once, each
+// source starts with the given cost rather than zero
+func initMultiSource(graph DirectedGraph, sources map[Vertex]float32) RelaxableAttributes {
+	dt := make(RelaxableAttributes)
+	for _, v := range graph.Vertices() {
+		dt[v] = &DijkstraAttribute{
+			ShortestEstimate: float32(math.Inf(1)),
+		}
+	}
+
+	for s, cost := range sources {
+		attr, ok := dt[s]
+		if !ok {
+			panic("source not in graph")
+		}
+		attr.SetShortestEstimateFromSource(cost)
+	}
+	return dt
+}
+
+// NearestSource maps each vertex reached by a multi source search to the source
+// it was reached from, which is the nearest source once any starting costs are
+// added. Sources map to themselves, unless another source reached them more cheaply
+type NearestSource map[Vertex]Vertex
+
+// Regions groups the vertices by their source, giving the cells of a graph Voronoi partition
+func (ns NearestSource) Regions() map[Vertex][]Vertex {
+	regions := make(map[Vertex][]Vertex)
+	for v, s := range ns {
+		regions[s] = append(regions[s], v)
+	}
+	return regions
+}
+
+// nearestSources follows each reached vertex's predecessors back to the source at the
+// root of its tree. Vertices with no predecessor are roots if reached is true for them
+func nearestSources(verts []Vertex, reached func(Vertex) bool, predecessor func(Vertex) Vertex) NearestSource {
+	ns := make(NearestSource)
+	for _, v := range verts {
+		if !reached(v) {
+			continue
+		}
+		walked := make([]Vertex, 0)
+		current := v
+		for {
+			if s, ok := ns[current]; ok {
+				current = s
+				break
+			}
+			walked = append(walked, current)
+			pre := predecessor(current)
+			if pre == nil {
+				break
+			}
+			current = pre
+		}
+		for _, w := range walked {
+			ns[w] = current
+		}
+	}
+	return ns
+}
+
+// MultiSourceBreadthFirstSearch searches outwards from all of the sources at once,
+// each source starts at the given distance (usually zero) so sources with a higher
+// start only claim the vertices that are that much closer to them. A source reached
+// from another source before its own start distance is treated as an ordinary vertex
+func MultiSourceBreadthFirstSearch(graph DirectedGraph, sources map[Vertex]int) (BreadthFirstTree, NearestSource) {
+	bfsTree := make(BreadthFirstTree)
+	for _, u := range graph.Vertices() {
+		bfsTree[u] = &BFSAttribute{
+			baseAttribute: &baseAttribute{
+				distance: -1,
+			},
+			Color: BFSWhite,
+		}
+	}
+
+	// sources are added to the queue as the search reaches their start distance,
+	// in the order of the graph's vertices for those that start together
+	pending := make([]Vertex, 0, len(sources))
+	for _, v := range graph.Vertices() {
+		if _, ok := sources[v]; ok {
+			pending = append(pending, v)
+		}
+	}
+	if len(pending) != len(sources) {
+		panic("source not in graph")
+	}
+	sort.SliceStable(pending, func(i, j int) bool {
+		return sources[pending[i]] < sources[pending[j]]
+	})
+
+	queue := list.New()
+	for queue.Len() > 0 || len(pending) > 0 {
+		// the front of the queue is always the nearest, so sources starting at that
+		// distance go in front of it, in order, to keep the queue in order. An empty
+		// queue takes the sources starting with the first of those left
+		front := queue.Front()
+		var reached int
+		if front != nil {
+			reached = bfsTree[front.Value.(Vertex)].distance
+		} else if len(pending) > 0 {
+			reached = sources[pending[0]]
+		}
+		for len(pending) > 0 && sources[pending[0]] <= reached {
+			s := pending[0]
+			pending = pending[1:]
+			if bfsTree[s].Color != BFSWhite {
+				continue
+			}
+			bfsTree[s].Color = BFSGray
+			bfsTree[s].distance = sources[s]
+			if front == nil {
+				queue.PushBack(s)
+			} else {
+				queue.InsertBefore(s, front)
+			}
+		}
+		if queue.Len() == 0 {
+			continue
+		}
+
+		fromVertex := queue.Remove(queue.Front()).(Vertex)
+		for _, edge := range graph.Edges()[fromVertex] {
+			toVertex := edge.To()
+			if bfsTree[toVertex].Color == BFSWhite {
+				bfsTree[toVertex].Color = BFSGray
+				bfsTree[toVertex].distance = bfsTree[fromVertex].distance + 1
+				bfsTree[toVertex].predecessor = fromVertex
+				queue.PushBack(toVertex)
+			}
+		}
+		bfsTree[fromVertex].Color = BFSBlack
+	}
+
+	ns := nearestSources(graph.Vertices(),
+		func(v Vertex) bool { return bfsTree[v].Color != BFSWhite },
+		func(v Vertex) Vertex { return bfsTree[v].predecessor })
+	return bfsTree, ns
+}
+
+// MultiSourceDijkstra is Dijkstra from all of the sources at once, each source
+// starts with the given cost (usually zero)
+func MultiSourceDijkstra(graph WeightedDigraph, sources map[Vertex]float32) (RelaxableAttributes, NearestSource) {
+	attrs := dijkstraLoop(graph, initMultiSource(graph, sources), nil)
+
+	ns := nearestSources(graph.Vertices(),
+		func(v Vertex) bool { return !isInf32(attrs[v].ShortestEstimateFromSource()) },
+		func(v Vertex) Vertex { return attrs[v].Predecessor() })
+	return attrs, ns
+}
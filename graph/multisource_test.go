@@ -0,0 +1,36 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestMultiSourceBreadthFirstSearchStaggeredStarts(t *testing.T) {
+	// a path 0-1-2-3-4-5, with 5 starting five steps behind 0
+	g := newTestGraph(0, 1, 2, 3, 4, 5)
+	for i := 0; i < 5; i++ {
+		g.addUndirectedEdge(i, i+1, 1)
+	}
+
+	tree, nearest := MultiSourceBreadthFirstSearch(g, map[Vertex]int{0: 0, 5: 5})
+	for v := 0; v < 6; v++ {
+		if tree[v].Distance() != v {
+			t.Errorf("vertex %d at distance %d, expected %d", v, tree[v].Distance(), v)
+		}
+		if nearest[v] != 0 {
+			t.Errorf("vertex %d reached from %v, expected 0", v, nearest[v])
+		}
+	}
+
+	// starting four behind, 5 is reached from 0 at the same distance it starts at, so
+	// it keeps itself, as the sources are queued first
+	tree, nearest = MultiSourceBreadthFirstSearch(g, map[Vertex]int{0: 0, 5: 4})
+	want := map[int]Vertex{0: 0, 1: 0, 2: 0, 3: 0, 4: 0, 5: 5}
+	for v, s := range want {
+		if nearest[v] != s {
+			t.Errorf("vertex %d reached from %v, expected %v", v, nearest[v], s)
+		}
+	}
+	if tree[4].Distance() != 4 {
+		t.Errorf("vertex 4 at distance %d, expected 4", tree[4].Distance())
+	}
+}
@@ -0,0 +1,217 @@
+package graph
+
+// ChangeKind says what a Change did to an ObservableGraph
+type ChangeKind uint8
+
+func (k ChangeKind) String() string {
+	switch k {
+	case VertexAdded:
+		return "vertex added"
+	case VertexRemoved:
+		return "vertex removed"
+	case EdgeAdded:
+		return "edge added"
+	case EdgeRemoved:
+		return "edge removed"
+	case EdgeReweighted:
+		return "edge reweighted"
+	default:
+		return ""
+	}
+}
+
+const (
+	VertexAdded ChangeKind = iota
+	VertexRemoved
+	EdgeAdded
+	EdgeRemoved
+	EdgeReweighted
+)
+
+// Change is a single change to an ObservableGraph, as sent to its subscribers. Vertex
+// is set for vertex changes and Edge for edge changes. Weight is the edge's weight
+// after the change, or the weight it had when it was removed, and OldWeight is its
+// weight before it was reweighted
+type Change struct {
+	Kind      ChangeKind
+	Vertex    Vertex
+	Edge      Edge
+	Weight    float32
+	OldWeight float32
+}
+
+type subscription struct {
+	notify func(Change)
+	closed bool
+}
+
+// WeightSetter is a weighted digraph that can change the weight of one of its edges
+type WeightSetter interface {
+	SetWeight(edge Edge, weight float32)
+}
+
+// ObservableGraph wraps a weighted digraph and tells its subscribers about every change
+// made through it, so anything that caches results about the graph can keep them up to
+// date rather than going stale. Changes made directly to the underlying graph aren't
+// seen. A vertex is added when an edge first touches it, as the graphs have no other
+// way to add one
+type ObservableGraph struct {
+	graph       WeightedDigraph
+	known       map[Vertex]bool
+	removed     map[Vertex]bool // removed vertices the underlying graph may still list
+	subscribers []*subscription
+}
+
+func NewObservableGraph(graph WeightedDigraph) *ObservableGraph {
+	og := ObservableGraph{
+		graph:   graph,
+		known:   make(map[Vertex]bool),
+		removed: make(map[Vertex]bool),
+	}
+	for _, v := range graph.Vertices() {
+		og.known[v] = true
+	}
+	return &og
+}
+
+// Subscribe calls notify with every change from now on, in the order they happen, and
+// returns a function that stops it. Subscribers are called in the order they subscribed,
+// before the change method returns
+func (og *ObservableGraph) Subscribe(notify func(Change)) func() {
+	s := &subscription{notify: notify}
+	og.subscribers = append(og.subscribers, s)
+	return func() {
+		s.closed = true
+		for i, sub := range og.subscribers {
+			if sub == s {
+				og.subscribers = append(og.subscribers[:i:i], og.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (og *ObservableGraph) emit(c Change) {
+	// unsubscribing copies the slice, so a subscriber can do it while being told, and
+	// marks the subscription closed so it isn't told about this change either
+	for _, s := range og.subscribers {
+		if !s.closed {
+			s.notify(c)
+		}
+	}
+}
+
+// Vertices returns the underlying graph's vertices, less any removed with RemoveVertex
+func (og *ObservableGraph) Vertices() []Vertex {
+	if len(og.removed) == 0 {
+		return og.graph.Vertices()
+	}
+	verts := make([]Vertex, 0)
+	for _, v := range og.graph.Vertices() {
+		if !og.removed[v] {
+			verts = append(verts, v)
+		}
+	}
+	return verts
+}
+
+func (og *ObservableGraph) Edges() map[Vertex][]Edge {
+	return og.graph.Edges()
+}
+
+func (og *ObservableGraph) Weights() map[Edge]float32 {
+	return og.graph.Weights()
+}
+
+// AddEdge adds the edge to the underlying graph, and sends VertexAdded for either end
+// the graph didn't have and then EdgeAdded
+func (og *ObservableGraph) AddEdge(from, to Vertex) {
+	existing := make(map[Edge]bool)
+	for _, e := range og.graph.Edges()[from] {
+		existing[e] = true
+	}
+	og.graph.AddEdge(from, to)
+
+	for _, v := range []Vertex{from, to} {
+		if !og.known[v] {
+			og.known[v] = true
+			delete(og.removed, v)
+			og.emit(Change{Kind: VertexAdded, Vertex: v})
+		}
+	}
+	for _, e := range og.graph.Edges()[from] {
+		if !existing[e] && e.To() == to {
+			og.emit(Change{Kind: EdgeAdded, Edge: e, Weight: og.graph.Weights()[e]})
+			return
+		}
+	}
+}
+
+// hasEdge returns true if edge is one of the underlying graph's edges
+func (og *ObservableGraph) hasEdge(edge Edge) bool {
+	for _, e := range og.graph.Edges()[edge.From()] {
+		if e == edge {
+			return true
+		}
+	}
+	return false
+}
+
+// RemoveEdge removes the edge from the underlying graph and sends EdgeRemoved, doing
+// nothing if the edge isn't in the graph
+func (og *ObservableGraph) RemoveEdge(edge Edge) {
+	if !og.hasEdge(edge) {
+		return
+	}
+	weight := og.graph.Weights()[edge]
+	og.graph.RemoveEdge(edge)
+	og.emit(Change{Kind: EdgeRemoved, Edge: edge, Weight: weight})
+}
+
+// SetWeight changes the weight of edge and sends EdgeReweighted, doing nothing if the
+// edge isn't in the graph. If the underlying graph is a WeightSetter it sets the weight.
+// Otherwise the weight is set in the map the graph's Weights returns, so the graph must
+// keep its weights in that map rather than handing out a copy, as the graph views and
+// CSRGraph do. SetWeight panics if it can see the weight didn't change
+func (og *ObservableGraph) SetWeight(edge Edge, weight float32) {
+	if !og.hasEdge(edge) {
+		return
+	}
+	weights := og.graph.Weights()
+	old := weights[edge]
+	if old == weight {
+		return
+	}
+	if ws, ok := og.graph.(WeightSetter); ok {
+		ws.SetWeight(edge, weight)
+	} else {
+		weights[edge] = weight
+		if og.graph.Weights()[edge] != weight {
+			panic("graph's weights can't be set through the map from Weights")
+		}
+	}
+	og.emit(Change{Kind: EdgeReweighted, Edge: edge, Weight: weight, OldWeight: old})
+}
+
+// RemoveVertex removes every edge into or out of v, sending EdgeRemoved for each, then
+// sends VertexRemoved. The underlying graph may still list v among its vertices, as the
+// graphs have no way to remove one, but Vertices won't until an edge touches it again
+func (og *ObservableGraph) RemoveVertex(v Vertex) {
+	touching := append([]Edge(nil), og.graph.Edges()[v]...)
+	for u, es := range og.graph.Edges() {
+		if u == v {
+			continue
+		}
+		for _, e := range es {
+			if e.To() == v {
+				touching = append(touching, e)
+			}
+		}
+	}
+	for _, e := range touching {
+		og.RemoveEdge(e)
+	}
+	delete(og.known, v)
+	og.removed[v] = true
+	og.emit(Change{Kind: VertexRemoved, Vertex: v})
+}
@@ -0,0 +1,127 @@
+package graph
+
+import (
+	"testing"
+)
+
+// recordChanges subscribes to og and returns the changes it is sent
+func recordChanges(og *ObservableGraph) *[]Change {
+	changes := make([]Change, 0)
+	og.Subscribe(func(c Change) {
+		changes = append(changes, c)
+	})
+	return &changes
+}
+
+func TestObservableGraphChanges(t *testing.T) {
+	g := newTestGraph(0, 1)
+	og := NewObservableGraph(g)
+	changes := recordChanges(og)
+
+	og.AddEdge(1, 2)
+	og.AddEdge(2, 3)
+	e := g.Edges()[1][0]
+	og.SetWeight(e, 4)
+	og.RemoveVertex(2)
+
+	want := []Change{
+		{Kind: VertexAdded, Vertex: 2},
+		{Kind: EdgeAdded, Edge: e, Weight: 1},
+		{Kind: VertexAdded, Vertex: 3},
+		{Kind: EdgeAdded, Weight: 1},
+		{Kind: EdgeReweighted, Edge: e, Weight: 4, OldWeight: 1},
+		{Kind: EdgeRemoved, Weight: 1},
+		{Kind: EdgeRemoved, Edge: e, Weight: 4},
+		{Kind: VertexRemoved, Vertex: 2},
+	}
+	if len(*changes) != len(want) {
+		t.Fatalf("sent %d changes, expected %d: %v", len(*changes), len(want), *changes)
+	}
+	for i, c := range *changes {
+		w := want[i]
+		if w.Edge == nil && c.Edge != nil {
+			// the edges out of 2 aren't held, so only their ends are checked
+			if c.Edge.From() != 2 || c.Edge.To() != 3 {
+				t.Errorf("change %d is for edge %v->%v, expected 2->3", i, c.Edge.From(), c.Edge.To())
+			}
+			c.Edge = nil
+		}
+		if c != w {
+			t.Errorf("change %d is %v, expected %v", i, c, w)
+		}
+	}
+}
+
+func TestObservableGraphMissingEdge(t *testing.T) {
+	g := newTestGraph(0, 1)
+	og := NewObservableGraph(g)
+	og.AddEdge(0, 1)
+	changes := recordChanges(og)
+
+	missing := NewEdge(1, 0)
+	og.RemoveEdge(missing)
+	og.SetWeight(missing, 3)
+	if len(*changes) != 0 {
+		t.Errorf("changes sent for an edge not in the graph: %v", *changes)
+	}
+	if _, ok := g.Weights()[missing]; ok {
+		t.Error("weight set for an edge not in the graph")
+	}
+}
+
+func TestObservableGraphUnsubscribeWhileSending(t *testing.T) {
+	og := NewObservableGraph(newTestGraph(0, 1))
+	var unsubscribe func()
+	told := 0
+	og.Subscribe(func(Change) {
+		unsubscribe()
+	})
+	unsubscribe = og.Subscribe(func(Change) {
+		told++
+	})
+
+	og.AddEdge(0, 1)
+	og.AddEdge(1, 0)
+	if told != 0 {
+		t.Errorf("subscriber unsubscribed before its turn was told %d changes", told)
+	}
+}
+
+func TestPathCacheInvalidation(t *testing.T) {
+	// a square 0-1-2-3-0, with the path from 0 to 2 through 1
+	g := newTestGraph(0, 1, 2, 3)
+	g.addUndirectedEdge(0, 1, 1)
+	g.addUndirectedEdge(1, 2, 1)
+	g.addUndirectedEdge(2, 3, 2)
+	g.addUndirectedEdge(3, 0, 2)
+	og := NewObservableGraph(g)
+	pc := NewPathCache(og, DijkstraPath)
+	defer pc.Close()
+
+	p, ok := pc.Path(0, 2)
+	if !ok || p.Cost != 2 {
+		t.Fatalf("path from 0 to 2 is %v, expected cost 2", p)
+	}
+	pc.Path(3, 0)
+	if pc.Len() != 2 {
+		t.Fatalf("cache holds %d paths, expected 2", pc.Len())
+	}
+
+	// removing an edge only drops the paths that use it
+	og.RemoveEdge(p.Edges[0])
+	if pc.Len() != 1 {
+		t.Errorf("cache holds %d paths after removing an edge, expected 1", pc.Len())
+	}
+	if p, _ = pc.Path(0, 2); p.Cost != 4 {
+		t.Errorf("path from 0 to 2 costs %f after removing an edge, expected 4", p.Cost)
+	}
+
+	// adding an edge could shorten any path, so drops them all
+	og.AddEdge(0, 2)
+	if pc.Len() != 0 {
+		t.Errorf("cache holds %d paths after adding an edge, expected none", pc.Len())
+	}
+	if p, _ = pc.Path(0, 2); p.Cost != 1 {
+		t.Errorf("path from 0 to 2 costs %f after adding an edge, expected 1", p.Cost)
+	}
+}
@@ -0,0 +1,243 @@
+package graph
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// the direction switching thresholds from Beamer et al, the search goes bottom up when
+// the frontier has more than 1/alpha of the unexplored edges, and back to top down
+// when the frontier is smaller than 1/beta of the vertices
+const (
+	bfsAlpha = 14
+	bfsBeta  = 24
+)
+
+// trySet atomically sets bit i, returning false if it was already set
+func (b bitset) trySet(i int) bool {
+	addr := &b[i/64]
+	mask := uint64(1) << uint(i%64)
+	for {
+		old := atomic.LoadUint64(addr)
+		if old&mask != 0 {
+			return false
+		}
+		if atomic.CompareAndSwapUint64(addr, old, old|mask) {
+			return true
+		}
+	}
+}
+
+func (b bitset) atomicHas(i int) bool {
+	return atomic.LoadUint64(&b[i/64])&(1<<uint(i%64)) != 0
+}
+
+// csrAdjacency is an adjacency list packed into two slices, the neighbours of
+// vertex i are targets[offsets[i]:offsets[i+1]]
+type csrAdjacency struct {
+	offsets []int
+	targets []int32
+}
+
+func (a *csrAdjacency) neighbors(i int) []int32 {
+	return a.targets[a.offsets[i]:a.offsets[i+1]]
+}
+
+func (a *csrAdjacency) degree(i int) int {
+	return a.offsets[i+1] - a.offsets[i]
+}
+
+// newCSRAdjacencies packs the graph's edges, and the reverse of them, into csr form
+func newCSRAdjacencies(graph DirectedGraph, index map[Vertex]int) (out, in *csrAdjacency) {
+	n := len(index)
+	out = &csrAdjacency{offsets: make([]int, n+1)}
+	in = &csrAdjacency{offsets: make([]int, n+1)}
+	edges := graph.Edges()
+	for i, v := range graph.Vertices() {
+		out.offsets[i+1] = out.offsets[i] + len(edges[v])
+	}
+	out.targets = make([]int32, out.offsets[n])
+	for i, v := range graph.Vertices() {
+		for j, e := range edges[v] {
+			to := index[e.To()]
+			out.targets[out.offsets[i]+j] = int32(to)
+			in.offsets[to+1]++
+		}
+	}
+
+	// the in edges are counted, so now they can be placed
+	for i := 0; i < n; i++ {
+		in.offsets[i+1] += in.offsets[i]
+	}
+	in.targets = make([]int32, in.offsets[n])
+	fill := make([]int, n)
+	copy(fill, in.offsets[:n])
+	for i := 0; i < n; i++ {
+		for _, to := range out.neighbors(i) {
+			in.targets[fill[to]] = int32(i)
+			fill[to]++
+		}
+	}
+	return out, in
+}
+
+// ParallelBreadthFirstSearch is a level synchronous BreadthFirstSearch spread over
+// workers goroutines (GOMAXPROCS if workers is zero or less). Each level is expanded
+// either top down, from the frontier along out edges, or bottom up, with every
+// unvisited vertex looking along its in edges for a parent in the frontier, whichever
+// is expected to check fewer edges. Distances match BreadthFirstSearch, but where a
+// vertex has several parents at the same distance any of them may be its predecessor.
+// Every vertex reached is black in the returned tree, the rest are white
+func ParallelBreadthFirstSearch(graph DirectedGraph, source Vertex, workers int) BreadthFirstTree {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	verts := graph.Vertices()
+	index := make(map[Vertex]int, len(verts))
+	for i, v := range verts {
+		index[v] = i
+	}
+	s, ok := index[source]
+	if !ok {
+		panic("source not in graph")
+	}
+	out, in := newCSRAdjacencies(graph, index)
+
+	n := len(verts)
+	distance := make([]int32, n)
+	parent := make([]int32, n)
+	for i := range distance {
+		distance[i] = -1
+		parent[i] = -1
+	}
+	visited := newBitset(n)
+	visited.set(s)
+	distance[s] = 0
+
+	frontier := []int32{int32(s)}
+	unexploredEdges := len(out.targets) - out.degree(s)
+	bottomUp := false
+	for level := int32(0); len(frontier) > 0; level++ {
+		frontierEdges := 0
+		for _, u := range frontier {
+			frontierEdges += out.degree(int(u))
+		}
+		switch {
+		case !bottomUp && frontierEdges > unexploredEdges/bfsAlpha:
+			bottomUp = true
+		case bottomUp && len(frontier) < n/bfsBeta:
+			bottomUp = false
+		}
+
+		var next []int32
+		if bottomUp {
+			next = bottomUpStep(in, frontier, visited, distance, parent, level, workers)
+		} else {
+			next = topDownStep(out, frontier, visited, distance, parent, level, workers)
+		}
+		for _, v := range next {
+			unexploredEdges -= out.degree(int(v))
+		}
+		frontier = next
+	}
+
+	bfsTree := make(BreadthFirstTree, n)
+	for i, v := range verts {
+		attr := &BFSAttribute{
+			baseAttribute: &baseAttribute{
+				distance: int(distance[i]),
+			},
+			Color: BFSWhite,
+		}
+		if distance[i] >= 0 {
+			attr.Color = BFSBlack
+		}
+		if parent[i] >= 0 {
+			attr.predecessor = verts[parent[i]]
+		}
+		bfsTree[v] = attr
+	}
+	return bfsTree
+}
+
+// parallelChunks splits [0, n) into one range per worker and runs work on each,
+// returning what each produced in range order
+func parallelChunks(n, workers int, work func(from, to int) []int32) []int32 {
+	chunk := (n + workers - 1) / workers
+	if chunk == 0 {
+		return nil
+	}
+	results := make([][]int32, workers)
+	wg := sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		from, to := w*chunk, (w+1)*chunk
+		if from >= n {
+			break
+		}
+		if to > n {
+			to = n
+		}
+		wg.Add(1)
+		go func(w, from, to int) {
+			defer wg.Done()
+			results[w] = work(from, to)
+		}(w, from, to)
+	}
+	wg.Wait()
+
+	total := 0
+	for _, r := range results {
+		total += len(r)
+	}
+	merged := make([]int32, 0, total)
+	for _, r := range results {
+		merged = append(merged, r...)
+	}
+	return merged
+}
+
+func topDownStep(out *csrAdjacency, frontier []int32, visited bitset, distance, parent []int32, level int32, workers int) []int32 {
+	return parallelChunks(len(frontier), workers, func(from, to int) []int32 {
+		next := make([]int32, 0)
+		for _, u := range frontier[from:to] {
+			for _, v := range out.neighbors(int(u)) {
+				if visited.trySet(int(v)) {
+					distance[v] = level + 1
+					parent[v] = u
+					next = append(next, v)
+				}
+			}
+		}
+		return next
+	})
+}
+
+func bottomUpStep(in *csrAdjacency, frontier []int32, visited bitset, distance, parent []int32, level int32, workers int) []int32 {
+	// distances are being written by the other workers, so the frontier is
+	// looked up in a bitset of its own
+	inFrontier := newBitset(len(distance))
+	for _, u := range frontier {
+		inFrontier.set(int(u))
+	}
+	return parallelChunks(len(distance), workers, func(from, to int) []int32 {
+		next := make([]int32, 0)
+		for v := from; v < to; v++ {
+			if visited.atomicHas(v) {
+				continue
+			}
+			for _, u := range in.neighbors(v) {
+				if inFrontier.has(int(u)) {
+					// only this worker looks at v, but the word is shared with its neighbours
+					visited.trySet(v)
+					distance[v] = level + 1
+					parent[v] = u
+					next = append(next, int32(v))
+					break
+				}
+			}
+		}
+		return next
+	})
+}
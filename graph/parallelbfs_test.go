@@ -0,0 +1,72 @@
+package graph
+
+import (
+	"math/rand"
+	"testing"
+)
+
+type benchGraph struct {
+	vertices []Vertex
+	edges    map[Vertex][]Edge
+}
+
+func (g *benchGraph) Vertices() []Vertex {
+	return g.vertices
+}
+
+func (g *benchGraph) Edges() map[Vertex][]Edge {
+	return g.edges
+}
+
+func (g *benchGraph) AddEdge(from, to Vertex) {
+	g.edges[from] = append(g.edges[from], NewEdge(from, to))
+}
+
+func (g *benchGraph) RemoveEdge(Edge) {}
+
+func newRandomBenchGraph(n, degree int, seed int64) *benchGraph {
+	r := rand.New(rand.NewSource(seed))
+	g := &benchGraph{
+		vertices: make([]Vertex, n),
+		edges:    make(map[Vertex][]Edge),
+	}
+	for i := range g.vertices {
+		g.vertices[i] = i
+	}
+	for i := 0; i < n*degree; i++ {
+		g.AddEdge(r.Intn(n), r.Intn(n))
+	}
+	return g
+}
+
+func TestParallelBreadthFirstSearch(t *testing.T) {
+	g := newRandomBenchGraph(5000, 3, 1)
+	seq := BreadthFirstSearch(g, 0)
+	for _, workers := range []int{1, 4} {
+		par := ParallelBreadthFirstSearch(g, 0, workers)
+		for _, v := range g.Vertices() {
+			if seq[v].distance != par[v].distance {
+				t.Fatalf("vertex %v distance %d, expected %d", v, par[v].distance, seq[v].distance)
+			}
+			if pre := par[v].predecessor; pre != nil && par[pre].distance != par[v].distance-1 {
+				t.Errorf("vertex %v has predecessor %v at distance %d", v, pre, par[pre].distance)
+			}
+		}
+	}
+}
+
+func BenchmarkBreadthFirstSearch(b *testing.B) {
+	g := newRandomBenchGraph(200000, 8, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BreadthFirstSearch(g, 0)
+	}
+}
+
+func BenchmarkParallelBreadthFirstSearch(b *testing.B) {
+	g := newRandomBenchGraph(200000, 8, 1)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ParallelBreadthFirstSearch(g, 0, 0)
+	}
+}
@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Path is a walk through a weighted digraph, stored both as the sequence of
+// vertices visited and the edges taken between them, along with the total weight
+// of those edges
+type Path struct {
+	Vertices []Vertex
+	Edges    []Edge
+	Cost     float32
+}
+
+func (p Path) String() string {
+	s := &strings.Builder{}
+	s.WriteString(fmt.Sprintf("(%.2f) ", p.Cost))
+	for i, v := range p.Vertices {
+		if i > 0 {
+			s.WriteString(" -> ")
+		}
+		s.WriteString(fmt.Sprint(v))
+	}
+	return s.String()
+}
+
+// Len returns the number of edges in the path
+func (p Path) Len() int {
+	return len(p.Edges)
+}
+
+// PathFromAttributes walks the predecessors in attrs back from destination to source
+// and returns the path between them. If there are parallel edges between two vertices
+// the cheapest is used. Returns false if destination was not reached from source
+func PathFromAttributes(graph WeightedDigraph, attrs RelaxableAttributes, source, destination Vertex) (Path, bool) {
+	if _, ok := attrs[destination]; !ok {
+		return Path{}, false
+	}
+
+	verts := []Vertex{destination}
+	for current := destination; current != source; {
+		pre := attrs[current].Predecessor()
+		if pre == nil || len(verts) > len(attrs) {
+			return Path{}, false
+		}
+		verts = append(verts, pre)
+		current = pre
+	}
+
+	//we walked backwards, so flip it round
+	for i, j := 0, len(verts)-1; i < j; i, j = i+1, j-1 {
+		verts[i], verts[j] = verts[j], verts[i]
+	}
+
+	return pathFromVertices(graph, verts)
+}
+
+// pathFromVertices builds a path through the given vertex sequence, taking the cheapest
+// edge between each consecutive pair
+func pathFromVertices(graph WeightedDigraph, verts []Vertex) (Path, bool) {
+	p := Path{
+		Vertices: verts,
+		Edges:    make([]Edge, 0, len(verts)),
+	}
+
+	edges := graph.Edges()
+	weights := graph.Weights()
+	for i := 0; i < len(verts)-1; i++ {
+		var best Edge
+		for _, edge := range edges[verts[i]] {
+			if edge.To() == verts[i+1] && (best == nil || weights[edge] < weights[best]) {
+				best = edge
+			}
+		}
+		if best == nil {
+			return Path{}, false
+		}
+		p.Edges = append(p.Edges, best)
+		p.Cost += weights[best]
+	}
+
+	return p, true
+}
@@ -0,0 +1,99 @@
+package graph
+
+// PathSearch finds a path from source to destination, or returns false if there isn't one
+type PathSearch func(graph WeightedDigraph, source, destination Vertex) (Path, bool)
+
+// DijkstraPath is a PathSearch using Dijkstra
+func DijkstraPath(graph WeightedDigraph, source, destination Vertex) (Path, bool) {
+	return PathFromAttributes(graph, Dijkstra(graph, source), source, destination)
+}
+
+type pathKey struct {
+	source, destination Vertex
+}
+
+type cachedPath struct {
+	path  Path
+	found bool
+}
+
+// PathCache remembers the paths found between pairs of vertices of an ObservableGraph
+// and forgets them when a change could make them wrong. Removing an edge or making it
+// heavier only affects the paths that use it, so only those are dropped, but adding an
+// edge or making one lighter could give a better path between any pair, so everything
+// is dropped. The search should find shortest paths for this to hold
+type PathCache struct {
+	graph       *ObservableGraph
+	search      PathSearch
+	paths       map[pathKey]cachedPath
+	using       map[Edge]map[pathKey]bool // the cached paths that use each edge
+	unsubscribe func()
+}
+
+// NewPathCache caches the paths search finds through og, Close stops it following og
+func NewPathCache(og *ObservableGraph, search PathSearch) *PathCache {
+	pc := PathCache{
+		graph:  og,
+		search: search,
+		paths:  make(map[pathKey]cachedPath),
+		using:  make(map[Edge]map[pathKey]bool),
+	}
+	pc.unsubscribe = og.Subscribe(pc.observe)
+	return &pc
+}
+
+// Path returns the path from source to destination, searching only if it isn't cached.
+// The path is shared with the cache, so must not be changed
+func (pc *PathCache) Path(source, destination Vertex) (Path, bool) {
+	key := pathKey{source: source, destination: destination}
+	if cached, ok := pc.paths[key]; ok {
+		return cached.path, cached.found
+	}
+
+	p, found := pc.search(pc.graph, source, destination)
+	pc.paths[key] = cachedPath{path: p, found: found}
+	for _, e := range p.Edges {
+		if pc.using[e] == nil {
+			pc.using[e] = make(map[pathKey]bool)
+		}
+		pc.using[e][key] = true
+	}
+	return p, found
+}
+
+// Len returns the number of cached paths
+func (pc *PathCache) Len() int {
+	return len(pc.paths)
+}
+
+// Invalidate forgets every cached path
+func (pc *PathCache) Invalidate() {
+	pc.paths = make(map[pathKey]cachedPath)
+	pc.using = make(map[Edge]map[pathKey]bool)
+}
+
+// Close stops following the graph's changes and empties the cache
+func (pc *PathCache) Close() {
+	pc.unsubscribe()
+	pc.Invalidate()
+}
+
+func (pc *PathCache) observe(c Change) {
+	switch {
+	case c.Kind == EdgeAdded, c.Kind == EdgeReweighted && c.Weight < c.OldWeight:
+		pc.Invalidate()
+	case c.Kind == EdgeRemoved, c.Kind == EdgeReweighted:
+		pc.forgetUsing(c.Edge)
+	}
+}
+
+// forgetUsing drops the cached paths that use edge
+func (pc *PathCache) forgetUsing(edge Edge) {
+	for key := range pc.using[edge] {
+		for _, e := range pc.paths[key].path.Edges {
+			delete(pc.using[e], key)
+		}
+		delete(pc.paths, key)
+	}
+	delete(pc.using, edge)
+}
@@ -1,5 +1,9 @@
 package graph
 
+// VertexPriorityItem is an item of a MinPriorityQueue.
+//
+// Deprecated: use the indexed queues of the priorityqueue package, which track
+// each key's position themselves.
 type VertexPriorityItem struct {
 	vertex   Vertex
 	priority *float32
@@ -20,6 +24,11 @@ func (i *VertexPriorityItem) Vertex() Vertex {
 	return i.vertex
 }
 
+// MinPriorityQueue is a container/heap min queue of vertices whose priorities are
+// read through pointers, so a changed priority must be fixed with heap.Fix.
+//
+// Deprecated: use priorityqueue.NewBinaryHeap(priorityqueue.MinFirst), which
+// supports DecreaseKey and Update by vertex.
 type MinPriorityQueue []*VertexPriorityItem
 
 func (pq MinPriorityQueue) Len() int {
@@ -0,0 +1,269 @@
+package graph
+
+import (
+	"container/list"
+	"fmt"
+	"math/bits"
+	"sort"
+)
+
+var (
+	ErrNotDAG = fmt.Errorf("graph has a cycle")
+)
+
+// Reachability answers whether one vertex can reach another. Every vertex can reach
+// itself, by a walk of no edges
+type Reachability interface {
+	Reachable(from, to Vertex) bool
+}
+
+type bitset []uint64
+
+func newBitset(size int) bitset {
+	return make(bitset, (size+63)/64)
+}
+
+func (b bitset) set(i int) {
+	b[i/64] |= 1 << uint(i%64)
+}
+
+func (b bitset) has(i int) bool {
+	return b[i/64]&(1<<uint(i%64)) != 0
+}
+
+func (b bitset) or(b1 bitset) {
+	for i := range b {
+		b[i] |= b1[i]
+	}
+}
+
+func (b bitset) count() int {
+	n := 0
+	for _, w := range b {
+		n += bits.OnesCount64(w)
+	}
+	return n
+}
+
+// Closure is the transitive closure of a graph stored as bitsets, which suits dense
+// graphs where most vertices reach most others. Reachability is stored per strongly
+// connected component, as every vertex in a component reaches the same vertices
+type Closure struct {
+	condensation *condensation
+	reach        []bitset // reach[c] has a bit for each component that c reaches
+}
+
+// TransitiveClosure works out which vertices each vertex can reach. Components are
+// handled sinks first, so each one's reach is itself plus the union of its successors'
+func TransitiveClosure(graph DirectedGraph) *Closure {
+	c := newCondensation(graph)
+	tc := Closure{
+		condensation: c,
+		reach:        make([]bitset, len(c.components)),
+	}
+	for i := range c.components {
+		tc.reach[i] = newBitset(len(c.components))
+		tc.reach[i].set(i)
+		for _, j := range c.successors[i] {
+			tc.reach[i].or(tc.reach[j])
+		}
+	}
+	return &tc
+}
+
+func (tc *Closure) Reachable(from, to Vertex) bool {
+	f, fok := tc.condensation.component[from]
+	t, tok := tc.condensation.component[to]
+	return fok && tok && tc.reach[f].has(t)
+}
+
+// ReachableFrom returns every vertex v can reach, including itself
+func (tc *Closure) ReachableFrom(v Vertex) []Vertex {
+	c, ok := tc.condensation.component[v]
+	if !ok {
+		return nil
+	}
+	out := make([]Vertex, 0, tc.reach[c].count())
+	for i, comp := range tc.condensation.components {
+		if tc.reach[c].has(i) {
+			out = append(out, comp...)
+		}
+	}
+	return out
+}
+
+// SparseClosure is the transitive closure of a graph stored as a set of reachable
+// vertices for each vertex, which suits sparse graphs where each vertex reaches few others
+type SparseClosure map[Vertex]map[Vertex]bool
+
+// SparseTransitiveClosure works out which vertices each vertex can reach with a breadth
+// first search from every vertex
+func SparseTransitiveClosure(graph DirectedGraph) SparseClosure {
+	sc := make(SparseClosure)
+	edges := graph.Edges()
+	for _, source := range graph.Vertices() {
+		reached := map[Vertex]bool{source: true}
+		queue := list.New()
+		queue.PushBack(source)
+		for queue.Len() > 0 {
+			u := queue.Remove(queue.Front()).(Vertex)
+			for _, e := range edges[u] {
+				if !reached[e.To()] {
+					reached[e.To()] = true
+					queue.PushBack(e.To())
+				}
+			}
+		}
+		sc[source] = reached
+	}
+	return sc
+}
+
+func (sc SparseClosure) Reachable(from, to Vertex) bool {
+	return sc[from][to]
+}
+
+// TransitiveReduction returns the fewest edges of a DAG that keep every vertex able to
+// reach the same vertices: an edge u -> v is dropped if v can be reached from another
+// of u's successors, and parallel edges are dropped down to one. Returns ErrNotDAG if
+// the graph has a cycle
+func TransitiveReduction(graph DirectedGraph) (map[Vertex][]Edge, error) {
+	tc := TransitiveClosure(graph)
+	for _, comp := range tc.condensation.components {
+		if len(comp) > 1 {
+			return nil, ErrNotDAG
+		}
+	}
+
+	kept := make(map[Vertex][]Edge)
+	for _, u := range graph.Vertices() {
+		es := graph.Edges()[u]
+		kept[u] = make([]Edge, 0)
+		taken := make(map[Vertex]bool)
+		for _, e := range es {
+			v := e.To()
+			if v == u {
+				return nil, ErrNotDAG
+			}
+			if taken[v] {
+				continue
+			}
+			redundant := false
+			for _, other := range es {
+				if w := other.To(); w != v && tc.Reachable(w, v) {
+					redundant = true
+					break
+				}
+			}
+			if !redundant {
+				kept[u] = append(kept[u], e)
+				taken[v] = true
+			}
+		}
+	}
+	return kept, nil
+}
+
+// ReachabilityIndex is a compact 2-hop labelling of a graph, built with pruned landmark
+// labelling. Every vertex gets a list of hub vertices it can reach, and a list of hubs
+// that reach it, chosen so that u reaches v exactly when the lists share a hub. The
+// lists are usually tiny, so queries take near constant time with far less memory than
+// a full transitive closure.
+type ReachabilityIndex struct {
+	condensation *condensation
+	out          [][]int // hubs (by rank) each component reaches, in rank order
+	in           [][]int // hubs each component is reached from, in rank order
+}
+
+// NewReachabilityIndex builds the labelling on the graph's condensation. Components are
+// made hubs in order of how many paths are likely to pass through them, and each one
+// is added to the labels of everything it reaches and is reached by, except where
+// the labels already answer the query
+func NewReachabilityIndex(graph DirectedGraph) *ReachabilityIndex {
+	c := newCondensation(graph)
+	n := len(c.components)
+	predecessors := make([][]int, n)
+	for i, succ := range c.successors {
+		for _, j := range succ {
+			predecessors[j] = append(predecessors[j], i)
+		}
+	}
+
+	order := make([]int, n)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		return (len(c.successors[a])+1)*(len(predecessors[a])+1) > (len(c.successors[b])+1)*(len(predecessors[b])+1)
+	})
+
+	ri := ReachabilityIndex{
+		condensation: c,
+		out:          make([][]int, n),
+		in:           make([][]int, n),
+	}
+	for rank, hub := range order {
+		ri.prunedSearch(hub, rank, c.successors, func(w int) bool {
+			return labelsMeet(ri.out[hub], ri.in[w])
+		}, ri.in)
+		ri.prunedSearch(hub, rank, predecessors, func(w int) bool {
+			return labelsMeet(ri.out[w], ri.in[hub])
+		}, ri.out)
+	}
+	return &ri
+}
+
+// prunedSearch is a breadth first search from hub that adds rank to the labels of every
+// component reached, but doesn't go past any component the labels already cover
+func (ri *ReachabilityIndex) prunedSearch(hub, rank int, next [][]int, covered func(int) bool, labels [][]int) {
+	visited := map[int]bool{hub: true}
+	queue := []int{hub}
+	for len(queue) > 0 {
+		w := queue[0]
+		queue = queue[1:]
+		if covered(w) {
+			continue
+		}
+		labels[w] = append(labels[w], rank)
+		for _, x := range next[w] {
+			if !visited[x] {
+				visited[x] = true
+				queue = append(queue, x)
+			}
+		}
+	}
+}
+
+// labelsMeet returns true if two sorted labels share a hub
+func labelsMeet(a, b []int) bool {
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] == b[j]:
+			return true
+		case a[i] < b[j]:
+			i++
+		default:
+			j++
+		}
+	}
+	return false
+}
+
+func (ri *ReachabilityIndex) Reachable(from, to Vertex) bool {
+	f, fok := ri.condensation.component[from]
+	t, tok := ri.condensation.component[to]
+	if !fok || !tok {
+		return false
+	}
+	return f == t || labelsMeet(ri.out[f], ri.in[t])
+}
+
+// LabelSize returns the total number of hub entries in the index
+func (ri *ReachabilityIndex) LabelSize() int {
+	n := 0
+	for i := range ri.out {
+		n += len(ri.out[i]) + len(ri.in[i])
+	}
+	return n
+}
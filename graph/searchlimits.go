@@ -0,0 +1,143 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// SearchStopReason says why a limited search stopped before finishing
+type SearchStopReason uint8
+
+func (r SearchStopReason) String() string {
+	switch r {
+	case SearchCancelled:
+		return "cancelled"
+	case SearchDeadline:
+		return "deadline exceeded"
+	case SearchMaxExpanded:
+		return "max expanded vertices reached"
+	case SearchMaxCost:
+		return "max cost reached"
+	default:
+		return ""
+	}
+}
+
+const (
+	SearchCancelled SearchStopReason = iota
+	SearchDeadline
+	SearchMaxExpanded
+	SearchMaxCost
+)
+
+// SearchStoppedError is returned by the limited searches when a limit stops them early,
+// alongside whatever partial results they had found. For cancellation and deadlines the
+// cause is the context error, so errors.Is(err, context.Canceled) works
+type SearchStoppedError struct {
+	Reason   SearchStopReason
+	Expanded int // vertices expanded before stopping
+	Cause    error
+}
+
+func (e *SearchStoppedError) Error() string {
+	return fmt.Sprintf("search stopped, %s after expanding %d vertices", e.Reason, e.Expanded)
+}
+
+func (e *SearchStoppedError) Unwrap() error {
+	return e.Cause
+}
+
+// SearchOption sets a limit on a search
+type SearchOption func(*searchLimits)
+
+// WithContext stops the search when ctx is cancelled or reaches its deadline
+func WithContext(ctx context.Context) SearchOption {
+	return func(l *searchLimits) {
+		l.ctx = ctx
+	}
+}
+
+// WithMaxExpanded stops the search once it has expanded n vertices
+func WithMaxExpanded(n int) SearchOption {
+	return func(l *searchLimits) {
+		l.maxExpanded = n
+	}
+}
+
+// WithMaxCost stops the search when the next vertex to expand costs more than cost. For
+// breadth first search the cost is the number of edges from the source, and for AStar
+// it is the total estimate through the vertex
+func WithMaxCost(cost float32) SearchOption {
+	return func(l *searchLimits) {
+		l.maxCost = cost
+	}
+}
+
+// WithDeadline stops the search at the given time
+func WithDeadline(deadline time.Time) SearchOption {
+	return func(l *searchLimits) {
+		l.deadline = deadline
+	}
+}
+
+// WithTimeout stops the search once it has been running for d
+func WithTimeout(d time.Duration) SearchOption {
+	return WithDeadline(time.Now().Add(d))
+}
+
+type searchLimits struct {
+	ctx         context.Context
+	maxExpanded int
+	maxCost     float32
+	deadline    time.Time
+	expanded    int
+	err         error
+}
+
+func newSearchLimits(opts []SearchOption) *searchLimits {
+	l := searchLimits{
+		maxCost: float32(math.Inf(1)),
+	}
+	for _, opt := range opts {
+		opt(&l)
+	}
+	return &l
+}
+
+// stop is called before expanding each vertex, with the cost of that vertex. It returns
+// true, and records why, if the search should stop. Unreachable vertices with infinite
+// cost don't count, as expanding them does nothing
+func (l *searchLimits) stop(cost float32) bool {
+	if l == nil || math.IsInf(float64(cost), 1) {
+		return false
+	}
+
+	reason := SearchStopReason(0)
+	var cause error
+	switch {
+	case l.ctx != nil && l.ctx.Err() != nil:
+		reason, cause = SearchCancelled, l.ctx.Err()
+		if errors.Is(cause, context.DeadlineExceeded) {
+			reason = SearchDeadline
+		}
+	case !l.deadline.IsZero() && !time.Now().Before(l.deadline):
+		reason, cause = SearchDeadline, context.DeadlineExceeded
+	case l.maxExpanded > 0 && l.expanded >= l.maxExpanded:
+		reason = SearchMaxExpanded
+	case cost > l.maxCost:
+		reason = SearchMaxCost
+	default:
+		l.expanded++
+		return false
+	}
+
+	l.err = &SearchStoppedError{
+		Reason:   reason,
+		Expanded: l.expanded,
+		Cause:    cause,
+	}
+	return true
+}
@@ -0,0 +1,430 @@
+package graph
+
+import (
+	"container/list"
+	"math"
+
+	"github.com/DaJobat/gogve/priorityqueue"
+)
+
+// SearchState is a graph search which can be stepped through one vertex at a time,
+// like sweepline.LSIState, so the progress of a search can be inspected or animated.
+// Step expands (or for DFS discovers) a single vertex and returns false once there is
+// nothing left to do, Current is the vertex expanded by the last Step and Next the
+// one the following Step will expand. Frontier is the set of vertices reached but not
+// yet finished with, and Settled those which are finished, in the order they finished
+type SearchState interface {
+	Step() bool
+	Done() bool
+	Current() Vertex
+	Next() Vertex
+	Frontier() []Vertex
+	Settled() []Vertex
+	Run()
+}
+
+type BFSState struct {
+	graph   DirectedGraph
+	tree    BreadthFirstTree
+	queue   *list.List
+	current Vertex
+	settled []Vertex
+}
+
+func NewBFSState(graph DirectedGraph, source Vertex) *BFSState {
+	s := &BFSState{
+		graph:   graph,
+		tree:    initBFSTree(graph, source),
+		queue:   list.New(),
+		settled: make([]Vertex, 0),
+	}
+	s.queue.PushBack(source)
+	return s
+}
+
+func (s *BFSState) Step() bool {
+	if s.queue.Len() == 0 {
+		return false
+	}
+	fromVertex := s.queue.Remove(s.queue.Front()).(Vertex)
+	for _, edge := range edgesFrom(s.graph, fromVertex) {
+		toVertex := edge.To()
+		if s.tree[toVertex].Color == BFSWhite {
+			s.tree[toVertex].Color = BFSGray
+			s.tree[toVertex].distance = s.tree[fromVertex].distance + 1
+			s.tree[toVertex].predecessor = fromVertex
+			s.queue.PushBack(toVertex)
+		}
+	}
+	s.tree[fromVertex].Color = BFSBlack
+
+	s.current = fromVertex
+	s.settled = append(s.settled, fromVertex)
+	return true
+}
+
+func (s *BFSState) Done() bool {
+	return s.queue.Len() == 0
+}
+
+func (s *BFSState) Current() Vertex {
+	return s.current
+}
+
+func (s *BFSState) Next() Vertex {
+	if s.queue.Len() == 0 {
+		return nil
+	}
+	return s.queue.Front().Value.(Vertex)
+}
+
+// Frontier returns the gray vertices in the order they will be expanded
+func (s *BFSState) Frontier() []Vertex {
+	verts := make([]Vertex, 0, s.queue.Len())
+	for e := s.queue.Front(); e != nil; e = e.Next() {
+		verts = append(verts, e.Value.(Vertex))
+	}
+	return verts
+}
+
+func (s *BFSState) Settled() []Vertex {
+	return s.settled
+}
+
+// Tree returns the breadth first tree as it stands, it is updated by each Step
+func (s *BFSState) Tree() BreadthFirstTree {
+	return s.tree
+}
+
+func (s *BFSState) Run() {
+	for s.Step() {
+	}
+}
+
+// dfsFrame is a vertex on the DFS stack, along with the index of the next of its edges to follow
+type dfsFrame struct {
+	vertex Vertex
+	next   int
+}
+
+// DFSState is an iterative depth first search. Like DepthFirstSearch it starts at the
+// source and then restarts from each vertex left undiscovered, in the order of the
+// graph's vertices. Each Step discovers one vertex, finishing any vertices whose edges
+// have all been followed on the way, so the final Step only finishes vertices and
+// returns false. Articulation points are not found
+type DFSState struct {
+	graph      DirectedGraph
+	tree       DFSTree
+	stack      []*dfsFrame
+	roots      []Vertex
+	time       int
+	discovered int
+	current    Vertex
+	settled    []Vertex
+}
+
+func NewDFSState(graph DirectedGraph, source Vertex) *DFSState {
+	s := &DFSState{
+		graph:   graph,
+		tree:    make(DFSTree),
+		stack:   make([]*dfsFrame, 0),
+		roots:   append([]Vertex{source}, graph.Vertices()...),
+		settled: make([]Vertex, 0),
+	}
+	for _, u := range graph.Vertices() {
+		s.tree[u] = &DFSAttribute{
+			color: BFSWhite,
+		}
+	}
+	if _, ok := s.tree[source]; !ok {
+		panic("source not in graph")
+	}
+	return s
+}
+
+func (s *DFSState) discover(v, pre Vertex) {
+	s.time++
+	s.tree[v].discoverTime = s.time
+	s.tree[v].color = BFSGray
+	s.tree[v].predecessor = pre
+	if pre != nil {
+		s.tree[pre].children++
+	}
+	s.stack = append(s.stack, &dfsFrame{vertex: v})
+	s.discovered++
+	s.current = v
+}
+
+func (s *DFSState) finish(v Vertex) {
+	s.time++
+	s.tree[v].finishTime = s.time
+	s.tree[v].color = BFSBlack
+	s.stack = s.stack[:len(s.stack)-1]
+	s.settled = append(s.settled, v)
+}
+
+func (s *DFSState) Step() bool {
+	for len(s.stack) > 0 {
+		top := s.stack[len(s.stack)-1]
+		edges := edgesFrom(s.graph, top.vertex)
+		for top.next < len(edges) {
+			to := edges[top.next].To()
+			top.next++
+			if s.tree[to].color == BFSWhite {
+				s.discover(to, top.vertex)
+				return true
+			}
+		}
+		s.finish(top.vertex)
+	}
+
+	for len(s.roots) > 0 {
+		root := s.roots[0]
+		s.roots = s.roots[1:]
+		if s.tree[root].color == BFSWhite {
+			s.discover(root, nil)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *DFSState) Done() bool {
+	return len(s.stack) == 0 && s.discovered == len(s.tree)
+}
+
+func (s *DFSState) Current() Vertex {
+	return s.current
+}
+
+// Next returns the vertex the next Step will discover, or nil if the search is done
+func (s *DFSState) Next() Vertex {
+	for i := len(s.stack) - 1; i >= 0; i-- {
+		frame := s.stack[i]
+		edges := edgesFrom(s.graph, frame.vertex)
+		for j := frame.next; j < len(edges); j++ {
+			if s.tree[edges[j].To()].color == BFSWhite {
+				return edges[j].To()
+			}
+		}
+	}
+	for _, root := range s.roots {
+		if s.tree[root].color == BFSWhite {
+			return root
+		}
+	}
+	return nil
+}
+
+// Frontier returns the gray vertices, which are the path from the current root
+// to the current vertex
+func (s *DFSState) Frontier() []Vertex {
+	verts := make([]Vertex, len(s.stack))
+	for i, frame := range s.stack {
+		verts[i] = frame.vertex
+	}
+	return verts
+}
+
+func (s *DFSState) Settled() []Vertex {
+	return s.settled
+}
+
+// Tree returns the depth first tree as it stands, it is updated by each Step
+func (s *DFSState) Tree() DFSTree {
+	return s.tree
+}
+
+func (s *DFSState) Run() {
+	for s.Step() {
+	}
+}
+
+type DijkstraState struct {
+	graph      WeightedDigraph
+	attributes RelaxableAttributes
+	queue      priorityqueue.Queue
+	outs       RelaxableAttributes
+	current    Vertex
+	settled    []Vertex
+}
+
+func NewDijkstraState(graph WeightedDigraph, source Vertex) *DijkstraState {
+	return newDijkstraState(graph, initSingleSource(graph, source))
+}
+
+func newDijkstraState(graph WeightedDigraph, attributes RelaxableAttributes) *DijkstraState {
+	return &DijkstraState{
+		graph:      graph,
+		attributes: attributes,
+		queue:      initDijkstraQueue(graph, attributes),
+		outs:       make(RelaxableAttributes),
+		settled:    make([]Vertex, 0),
+	}
+}
+
+func (s *DijkstraState) Step() bool {
+	if s.queue.Len() == 0 {
+		return false
+	}
+	key, _ := s.queue.Pop()
+	nextShortest := key.(Vertex)
+	for _, edge := range edgesFrom(s.graph, nextShortest) {
+		if Relax(s.graph, edge, s.attributes) {
+			s.queue.DecreaseKey(edge.To(), float64(s.attributes[edge.To()].ShortestEstimateFromSource()))
+		}
+	}
+
+	s.outs[nextShortest] = s.attributes[nextShortest]
+	s.current = nextShortest
+	s.settled = append(s.settled, nextShortest)
+	return true
+}
+
+func (s *DijkstraState) Done() bool {
+	return s.queue.Len() == 0
+}
+
+func (s *DijkstraState) Current() Vertex {
+	return s.current
+}
+
+func (s *DijkstraState) Next() Vertex {
+	if s.queue.Len() == 0 {
+		return nil
+	}
+	key, _ := s.queue.Peek()
+	return key.(Vertex)
+}
+
+// Frontier returns the vertices which have been reached but not settled
+func (s *DijkstraState) Frontier() []Vertex {
+	verts := make([]Vertex, 0)
+	for _, v := range s.graph.Vertices() {
+		if p, queued := s.queue.Priority(v); queued && !math.IsInf(p, 1) {
+			verts = append(verts, v)
+		}
+	}
+	return verts
+}
+
+func (s *DijkstraState) Settled() []Vertex {
+	return s.settled
+}
+
+// Attributes returns the attributes of the settled vertices, as Dijkstra does
+func (s *DijkstraState) Attributes() RelaxableAttributes {
+	return s.outs
+}
+
+func (s *DijkstraState) Run() {
+	for s.Step() {
+	}
+}
+
+type AStarState struct {
+	graph          WeightedDigraph
+	destination    Vertex
+	attrs          HeuristicAttributes
+	relaxableAttrs RelaxableAttributes
+	queue          priorityqueue.Queue
+	outs           HeuristicAttributes
+	current        Vertex
+	settled        []Vertex
+}
+
+func NewAStarState(wg WeightedDigraph, source, destination EstimatedVertex) *AStarState {
+	return newAStarState(wg, source, destination, EstimatedVertexHeuristic)
+}
+
+// NewAStarHeuristicState is NewAStarState using the given heuristic, as AStarHeuristic
+func NewAStarHeuristicState(wg WeightedDigraph, source, destination Vertex, h Heuristic) *AStarState {
+	return newAStarState(wg, source, destination, h)
+}
+
+func newAStarState(wg WeightedDigraph, source, destination Vertex, h Heuristic) *AStarState {
+	s := &AStarState{
+		graph:          wg,
+		destination:    destination,
+		attrs:          initAStarSingleSource(wg, source, destination, h),
+		relaxableAttrs: make(RelaxableAttributes),
+		queue:          priorityqueue.NewBinaryHeap(priorityqueue.MinFirst),
+		outs:           make(HeuristicAttributes),
+		settled:        make([]Vertex, 0),
+	}
+	for v, a := range s.attrs {
+		s.relaxableAttrs[v] = a
+	}
+
+	s.queue.Push(source, float64(s.attrs[source].TotalCostEstimate()))
+	return s
+}
+
+func (s *AStarState) Step() bool {
+	if s.Done() {
+		return false
+	}
+	key, _ := s.queue.Pop()
+	current := key.(Vertex)
+	s.outs[current] = s.attrs[current]
+	s.current = current
+	s.settled = append(s.settled, current)
+	if current == s.destination {
+		return true
+	}
+
+	for _, edge := range edgesFrom(s.graph, current) {
+		if _, settled := s.outs[edge.To()]; settled {
+			continue
+		}
+		if Relax(s.graph, edge, s.relaxableAttrs) {
+			s.queue.Update(edge.To(), float64(s.attrs[edge.To()].TotalCostEstimate()))
+		}
+	}
+	return true
+}
+
+// Done returns true once the destination has been expanded, or there is nothing left to expand
+func (s *AStarState) Done() bool {
+	if _, found := s.outs[s.destination]; found {
+		return true
+	}
+	return s.queue.Len() == 0
+}
+
+func (s *AStarState) Current() Vertex {
+	return s.current
+}
+
+func (s *AStarState) Next() Vertex {
+	if s.Done() {
+		return nil
+	}
+	key, _ := s.queue.Peek()
+	return key.(Vertex)
+}
+
+// Frontier returns the open set, the vertices which have been reached but not expanded
+func (s *AStarState) Frontier() []Vertex {
+	verts := make([]Vertex, 0)
+	for _, v := range s.graph.Vertices() {
+		if s.queue.Contains(v) {
+			verts = append(verts, v)
+		}
+	}
+	return verts
+}
+
+func (s *AStarState) Settled() []Vertex {
+	return s.settled
+}
+
+// Attributes returns the attributes of the expanded vertices, as AStarHeuristic does
+func (s *AStarState) Attributes() HeuristicAttributes {
+	return s.outs
+}
+
+func (s *AStarState) Run() {
+	for s.Step() {
+	}
+}
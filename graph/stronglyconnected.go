@@ -0,0 +1,113 @@
+package graph
+
+// StronglyConnectedComponents splits the graph into groups of vertices that can all
+// reach each other, using Tarjan's algorithm. Components are returned in reverse
+// topological order: no component has an edge to a component that comes after it
+func StronglyConnectedComponents(graph DirectedGraph) [][]Vertex {
+	edges := graph.Edges()
+	index := make(map[Vertex]int)
+	lowlink := make(map[Vertex]int)
+	onStack := make(map[Vertex]bool)
+	stack := make([]Vertex, 0)
+	components := make([][]Vertex, 0)
+
+	// the search is iterative, so large graphs don't overflow the call stack.
+	// Each frame is a vertex and how many of its edges have been followed
+	type frame struct {
+		vertex Vertex
+		next   int
+	}
+
+	counter := 0
+	for _, root := range graph.Vertices() {
+		if _, seen := index[root]; seen {
+			continue
+		}
+
+		frames := []frame{{vertex: root}}
+		index[root], lowlink[root] = counter, counter
+		counter++
+		stack = append(stack, root)
+		onStack[root] = true
+
+		for len(frames) > 0 {
+			f := &frames[len(frames)-1]
+			v := f.vertex
+			if f.next < len(edges[v]) {
+				w := edges[v][f.next].To()
+				f.next++
+				if _, seen := index[w]; !seen {
+					index[w], lowlink[w] = counter, counter
+					counter++
+					stack = append(stack, w)
+					onStack[w] = true
+					frames = append(frames, frame{vertex: w})
+				} else if onStack[w] && index[w] < lowlink[v] {
+					lowlink[v] = index[w]
+				}
+				continue
+			}
+
+			// all of v's edges are done, so v is the root of a component if nothing
+			// it reaches gets back above it
+			if lowlink[v] == index[v] {
+				component := make([]Vertex, 0)
+				for {
+					w := stack[len(stack)-1]
+					stack = stack[:len(stack)-1]
+					onStack[w] = false
+					component = append(component, w)
+					if w == v {
+						break
+					}
+				}
+				components = append(components, component)
+			}
+
+			frames = frames[:len(frames)-1]
+			if len(frames) > 0 {
+				parent := frames[len(frames)-1].vertex
+				if lowlink[v] < lowlink[parent] {
+					lowlink[parent] = lowlink[v]
+				}
+			}
+		}
+	}
+
+	return components
+}
+
+// condensation is the DAG made by shrinking each strongly connected component to a
+// single vertex, numbered by their position in StronglyConnectedComponents
+type condensation struct {
+	components [][]Vertex
+	component  map[Vertex]int
+	successors [][]int // distinct components each component has edges to
+}
+
+func newCondensation(graph DirectedGraph) *condensation {
+	c := condensation{
+		components: StronglyConnectedComponents(graph),
+		component:  make(map[Vertex]int),
+	}
+	for i, comp := range c.components {
+		for _, v := range comp {
+			c.component[v] = i
+		}
+	}
+
+	c.successors = make([][]int, len(c.components))
+	edges := graph.Edges()
+	for i, comp := range c.components {
+		seen := map[int]bool{i: true}
+		for _, v := range comp {
+			for _, e := range edges[v] {
+				if j := c.component[e.To()]; !seen[j] {
+					seen[j] = true
+					c.successors[i] = append(c.successors[i], j)
+				}
+			}
+		}
+	}
+	return &c
+}
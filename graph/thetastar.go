@@ -0,0 +1,180 @@
+package graph
+
+import (
+	"math"
+
+	"github.com/DaJobat/gogve/priorityqueue"
+	"github.com/DaJobat/gogve/util"
+)
+
+// GridVertex is an estimated vertex that sits on a cell of a 2d grid
+type GridVertex interface {
+	EstimatedVertex
+	GridPosition() (x, y int)
+}
+
+// WalkableFunc reports whether the grid cell at x, y can be walked through
+type WalkableFunc func(x, y int) bool
+
+// SupercoverLine returns every grid cell touched by the line between the centres of
+// two cells. Where the line passes exactly through a corner, both cells either side
+// of the corner are included
+func SupercoverLine(x0, y0, x1, y1 int) [][2]int {
+	dx, dy := x1-x0, y1-y0
+	sx, sy := 1, 1
+	if dx < 0 {
+		sx, dx = -1, -dx
+	}
+	if dy < 0 {
+		sy, dy = -1, -dy
+	}
+
+	cells := make([][2]int, 0, 1+dx+dy)
+	x, y := x0, y0
+	err := dx - dy
+	dx, dy = dx*2, dy*2
+	for n := (dx + dy) / 2; ; n-- {
+		cells = append(cells, [2]int{x, y})
+		if n <= 0 {
+			break
+		}
+		switch {
+		case err > 0:
+			x += sx
+			err -= dy
+		case err < 0:
+			y += sy
+			err += dx
+		default:
+			// through a corner
+			cells = append(cells, [2]int{x + sx, y}, [2]int{x, y + sy})
+			x += sx
+			y += sy
+			err += dx - dy
+			n--
+		}
+	}
+
+	return cells
+}
+
+// LineOfSight returns true if every cell on the line between from and to is walkable
+func LineOfSight(from, to GridVertex, walkable WalkableFunc) bool {
+	x0, y0 := from.GridPosition()
+	x1, y1 := to.GridPosition()
+	for _, c := range SupercoverLine(x0, y0, x1, y1) {
+		if !walkable(c[0], c[1]) {
+			return false
+		}
+	}
+	return true
+}
+
+func gridDistance(from, to GridVertex) float32 {
+	x0, y0 := from.GridPosition()
+	x1, y1 := to.GridPosition()
+	return float32(math.Hypot(float64(x1-x0), float64(y1-y0)))
+}
+
+// ThetaStar is an any angle version of AStar for grids. When a vertex is reached it
+// is connected straight to its predecessor's predecessor if there is line of sight
+// between them, so predecessors need not be neighbours and the path isn't limited to
+// grid steps. Straight line connections cost the euclidean distance between cells,
+// so edge weights of neighbouring cells should be euclidean too.
+//
+// The attributes are the same as AStar's, and waypoints holds the cell positions
+// along the path, or is nil if destination can't be reached
+func ThetaStar(wg WeightedDigraph, source, destination GridVertex, walkable WalkableFunc) (attrs AStarAttributes, waypoints []util.FVec) {
+	return thetaStar(wg, source, destination, walkable, false)
+}
+
+// LazyThetaStar is ThetaStar which delays line of sight checks until a vertex is
+// expanded, rather than checking every time a vertex is reached. This does far fewer
+// checks, which matters when they are expensive. If the check fails the vertex is
+// instead connected to its cheapest expanded neighbour, which assumes edges between
+// neighbouring cells have the same weight in both directions
+func LazyThetaStar(wg WeightedDigraph, source, destination GridVertex, walkable WalkableFunc) (attrs AStarAttributes, waypoints []util.FVec) {
+	return thetaStar(wg, source, destination, walkable, true)
+}
+
+func thetaStar(wg WeightedDigraph, source, destination GridVertex, walkable WalkableFunc, lazy bool) (AStarAttributes, []util.FVec) {
+	attrs := initAStarSingleSource(wg, source, destination, EstimatedVertexHeuristic)
+	parent := func(v Vertex) GridVertex {
+		if pre := attrs[v].Predecessor(); pre != nil {
+			return pre.(GridVertex)
+		}
+		return v.(GridVertex)
+	}
+	// relax tries to improve the cost of reaching to via from
+	relax := func(from, to GridVertex, cost float32) bool {
+		toAttr := attrs[to]
+		if c := attrs[from].ShortestEstimateFromSource() + cost; c < toAttr.ShortestEstimateFromSource() {
+			toAttr.SetShortestEstimateFromSource(c)
+			toAttr.SetPredecessor(from)
+			return true
+		}
+		return false
+	}
+
+	outs := make(AStarAttributes)
+	queue := priorityqueue.NewBinaryHeap(priorityqueue.MinFirst)
+	queue.Push(source, float64(attrs[source].TotalCostEstimate()))
+	weights := wg.Weights()
+
+	for queue.Len() > 0 {
+		key, _ := queue.Pop()
+		current := key.(GridVertex)
+
+		if lazy && current != source && !LineOfSight(parent(current), current, walkable) {
+			// the optimistic shortcut was blocked, so fall back to the best expanded neighbour
+			attrs[current].SetShortestEstimateFromSource(float32Inf)
+			for _, edge := range wg.Edges()[current] {
+				if n, ok := edge.To().(GridVertex); ok && outs[n] != nil {
+					relax(n, current, weights[edge])
+				}
+			}
+		}
+
+		outs[current] = attrs[current]
+		if current == destination {
+			break
+		}
+
+		for _, edge := range wg.Edges()[current] {
+			next := edge.To().(GridVertex)
+			if outs[next] != nil {
+				continue
+			}
+
+			var relaxed bool
+			grandparent := parent(current)
+			if current != source && (lazy || LineOfSight(grandparent, next, walkable)) {
+				relaxed = relax(grandparent, next, gridDistance(grandparent, next))
+			} else {
+				relaxed = relax(current, next, weights[edge])
+			}
+
+			if relaxed {
+				queue.Update(next, float64(attrs[next].TotalCostEstimate()))
+			}
+		}
+	}
+
+	if outs[destination] == nil {
+		return outs, nil
+	}
+
+	waypoints := make([]util.FVec, 0)
+	for v := GridVertex(destination); ; v = parent(v) {
+		x, y := v.GridPosition()
+		waypoints = append(waypoints, util.NewFVec2(float64(x), float64(y)))
+		if v == source {
+			break
+		}
+	}
+	for i, j := 0, len(waypoints)-1; i < j; i, j = i+1, j-1 {
+		waypoints[i], waypoints[j] = waypoints[j], waypoints[i]
+	}
+
+	return outs, waypoints
+}
@@ -0,0 +1,397 @@
+package graph
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/DaJobat/gogve/util"
+)
+
+var (
+	ErrTooManyWaypoints = fmt.Errorf("too many waypoints for an exact tour")
+)
+
+// heldKarpLimit is the most waypoints HeldKarp will solve, its memory use doubles
+// with every waypoint added, to around 90MB at 20
+var heldKarpLimit = 20
+
+// solveExactLimit is the most waypoints SolveTSP will solve exactly, above this the
+// heuristics are much faster
+var solveExactLimit = 12
+
+// DistanceMatrix holds the cost of travelling between each pair of waypoints,
+// d[i][j] is the cost from i to j. It need not be symmetric
+type DistanceMatrix [][]float64
+
+// PointDistances returns the straight line distances between points
+func PointDistances(points []util.FVec) DistanceMatrix {
+	d := make(DistanceMatrix, len(points))
+	for i, p := range points {
+		d[i] = make([]float64, len(points))
+		for j, q := range points {
+			sum := 0.0
+			for k := 0; k < p.Degree(); k++ {
+				sum += (q.M(k) - p.M(k)) * (q.M(k) - p.M(k))
+			}
+			d[i][j] = math.Sqrt(sum)
+		}
+	}
+	return d
+}
+
+// GraphDistances returns the shortest path costs between waypoints in a graph,
+// unreachable waypoints are infinitely far apart
+func GraphDistances(graph WeightedDigraph, waypoints []Vertex) DistanceMatrix {
+	d := make(DistanceMatrix, len(waypoints))
+	for i, w := range waypoints {
+		attrs := Dijkstra(graph, w)
+		d[i] = make([]float64, len(waypoints))
+		for j, x := range waypoints {
+			d[i][j] = float64(attrs[x].ShortestEstimateFromSource())
+		}
+	}
+	return d
+}
+
+// WithFreeReturn returns a copy of the matrix where getting back to start costs nothing,
+// so the best tour is the best open path beginning at start
+func (d DistanceMatrix) WithFreeReturn(start int) DistanceMatrix {
+	out := make(DistanceMatrix, len(d))
+	for i := range d {
+		out[i] = make([]float64, len(d[i]))
+		copy(out[i], d[i])
+		out[i][start] = 0
+	}
+	return out
+}
+
+func (d DistanceMatrix) symmetric() bool {
+	for i := range d {
+		for j := range d {
+			if d[i][j] != d[j][i] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Tour is an order to visit every waypoint in, returning to Order[0] at the end
+type Tour struct {
+	Order []int
+	Cost  float64
+}
+
+func newTour(d DistanceMatrix, order []int) Tour {
+	t := Tour{Order: order}
+	for i := range order {
+		t.Cost += d[order[i]][order[(i+1)%len(order)]]
+	}
+	return t
+}
+
+// rotate returns the tour starting from waypoint start
+func (t Tour) rotate(start int) Tour {
+	for i, w := range t.Order {
+		if w == start {
+			t.Order = append(append([]int{}, t.Order[i:]...), t.Order[:i]...)
+			break
+		}
+	}
+	return t
+}
+
+// NearestNeighborTour builds a tour by always travelling to the closest unvisited waypoint
+func NearestNeighborTour(d DistanceMatrix, start int) Tour {
+	n := len(d)
+	if n == 0 {
+		return Tour{}
+	}
+	visited := make([]bool, n)
+	order := make([]int, 0, n)
+	for current := start; current >= 0; {
+		visited[current] = true
+		order = append(order, current)
+		next := -1
+		for j := 0; j < n; j++ {
+			if !visited[j] && (next < 0 || d[current][j] < d[current][next]) {
+				next = j
+			}
+		}
+		current = next
+	}
+	return newTour(d, order)
+}
+
+// ChristofidesTour builds a tour at most one and a half times the length of the best
+// one, for symmetric distances that obey the triangle inequality. A minimum spanning
+// tree is joined with a minimum matching of its odd degree waypoints, and the eulerian
+// circuit of the result is walked, skipping waypoints already visited. The matching is
+// only exact for up to exactMatchingLimit odd waypoints, beyond that it is greedy and
+// the guarantee is lost. The tour starts at waypoint 0
+func ChristofidesTour(d DistanceMatrix) Tour {
+	n := len(d)
+	if n < 3 {
+		order := make([]int, n)
+		for i := range order {
+			order[i] = i
+		}
+		return newTour(d, order)
+	}
+
+	// prim's minimum spanning tree
+	inTree := make([]bool, n)
+	best := make([]float64, n)
+	parent := make([]int, n)
+	for i := range best {
+		best[i] = math.Inf(1)
+		parent[i] = -1
+	}
+	best[0] = 0
+	degree := make([]int, n)
+	edges := make([]eulerEdge, 0, n)
+	for k := 0; k < n; k++ {
+		u := -1
+		for i := 0; i < n; i++ {
+			if !inTree[i] && (u < 0 || best[i] < best[u]) {
+				u = i
+			}
+		}
+		inTree[u] = true
+		if parent[u] >= 0 {
+			edges = append(edges, eulerEdge{edge: NewEdge(parent[u], u), from: parent[u], to: u})
+			degree[u]++
+			degree[parent[u]]++
+		}
+		for v := 0; v < n; v++ {
+			if !inTree[v] && d[u][v] < best[v] {
+				best[v] = d[u][v]
+				parent[v] = u
+			}
+		}
+	}
+
+	odd := make([]int, 0)
+	for i, deg := range degree {
+		if deg%2 == 1 {
+			odd = append(odd, i)
+		}
+	}
+	dist := make([][]float64, len(odd))
+	for i, u := range odd {
+		dist[i] = make([]float64, len(odd))
+		for j, v := range odd {
+			dist[i][j] = d[u][v]
+		}
+	}
+	for _, pair := range minimumPairing(dist) {
+		u, v := odd[pair[0]], odd[pair[1]]
+		edges = append(edges, eulerEdge{edge: NewEdge(u, v), from: u, to: v})
+	}
+
+	circuit, _ := hierholzer(0, edges, true)
+	seen := make([]bool, n)
+	order := make([]int, 0, n)
+	for _, v := range circuit {
+		if i := v.(int); !seen[i] {
+			seen[i] = true
+			order = append(order, i)
+		}
+	}
+	return newTour(d, order)
+}
+
+// TwoOpt improves a tour by reversing sections of it wherever that makes it shorter,
+// until no reversal helps. Reversals are costed in full, so asymmetric distances work.
+// The first waypoint of the tour stays first
+func TwoOpt(d DistanceMatrix, tour Tour) Tour {
+	order := append([]int{}, tour.Order...)
+	n := len(order)
+	if n < 4 {
+		return newTour(d, order)
+	}
+
+	// forward[i] is the cost of the tour from order[0] to order[i], backward[i] is the
+	// cost of walking that same stretch in reverse
+	forward := make([]float64, n)
+	backward := make([]float64, n)
+	prefix := func() {
+		for i := 1; i < n; i++ {
+			forward[i] = forward[i-1] + d[order[i-1]][order[i]]
+			backward[i] = backward[i-1] + d[order[i]][order[i-1]]
+		}
+	}
+	prefix()
+
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < n-2; i++ {
+			for j := i + 2; j < n; j++ {
+				// reverse order[i+1..j]
+				a, b, c, e := order[i], order[i+1], order[j], order[(j+1)%n]
+				if (j+1)%n == i {
+					continue
+				}
+				before := d[a][b] + (forward[j] - forward[i+1]) + d[c][e]
+				after := d[a][c] + (backward[j] - backward[i+1]) + d[b][e]
+				if after < before-1e-9 {
+					for l, r := i+1, j; l < r; l, r = l+1, r-1 {
+						order[l], order[r] = order[r], order[l]
+					}
+					prefix()
+					improved = true
+				}
+			}
+		}
+	}
+
+	return newTour(d, order)
+}
+
+// OrOpt improves a tour by moving runs of one to three waypoints to a better place in
+// the tour, keeping their direction, until no move helps. The first waypoint of the
+// tour stays first
+func OrOpt(d DistanceMatrix, tour Tour) Tour {
+	order := append([]int{}, tour.Order...)
+	n := len(order)
+	if n < 4 {
+		return newTour(d, order)
+	}
+
+	for improved := true; improved; {
+		improved = false
+		for length := 1; length <= 3 && !improved; length++ {
+			for s := 1; s+length <= n && !improved; s++ {
+				e := s + length - 1
+				prev, next := order[s-1], order[(e+1)%n]
+				first, last := order[s], order[e]
+				removeGain := d[prev][first] + d[last][next] - d[prev][next]
+
+				for k := 0; k < n; k++ {
+					// insert between order[k] and order[k+1], outside the run
+					if k >= s-1 && k <= e {
+						continue
+					}
+					x, y := order[k], order[(k+1)%n]
+					if removeGain-(d[x][first]+d[last][y]-d[x][y]) > 1e-9 {
+						run := append([]int{}, order[s:e+1]...)
+						rest := append(append([]int{}, order[:s]...), order[e+1:]...)
+						at := k + 1
+						if k > e {
+							at -= length
+						}
+						order = append(append(append([]int{}, rest[:at]...), run...), rest[at:]...)
+						improved = true
+						break
+					}
+				}
+			}
+		}
+	}
+
+	return newTour(d, order)
+}
+
+// HeldKarp finds the best tour exactly with dynamic programming over subsets of
+// waypoints, for up to heldKarpLimit waypoints. The tour starts at waypoint 0
+func HeldKarp(d DistanceMatrix) (Tour, error) {
+	n := len(d)
+	if n > heldKarpLimit {
+		return Tour{}, ErrTooManyWaypoints
+	}
+	if n < 3 {
+		order := make([]int, n)
+		for i := range order {
+			order[i] = i
+		}
+		return newTour(d, order), nil
+	}
+
+	// cost[mask*m+j] is the cheapest path from waypoint 0 through the waypoints in mask,
+	// ending at j. Waypoint 0 is left out of the masks, so waypoint i is bit i-1
+	m := n - 1
+	full := 1<<uint(m) - 1
+	cost := make([]float64, (full+1)*m)
+	from := make([]uint8, (full+1)*m)
+	for i := range cost {
+		cost[i] = math.Inf(1)
+	}
+	for j := 0; j < m; j++ {
+		cost[(1<<uint(j))*m+j] = d[0][j+1]
+	}
+
+	for mask := 1; mask <= full; mask++ {
+		for j := 0; j < m; j++ {
+			c := cost[mask*m+j]
+			if mask&(1<<uint(j)) == 0 || math.IsInf(c, 1) {
+				continue
+			}
+			for k := 0; k < m; k++ {
+				if mask&(1<<uint(k)) != 0 {
+					continue
+				}
+				next := mask | 1<<uint(k)
+				if nc := c + d[j+1][k+1]; nc < cost[next*m+k] {
+					cost[next*m+k] = nc
+					from[next*m+k] = uint8(j)
+				}
+			}
+		}
+	}
+
+	last, bestCost := 0, math.Inf(1)
+	for j := 0; j < m; j++ {
+		if c := cost[full*m+j] + d[j+1][0]; c < bestCost {
+			last, bestCost = j, c
+		}
+	}
+
+	order := make([]int, n)
+	for mask, j, i := full, last, n-1; i > 0; i-- {
+		order[i] = j + 1
+		prev := int(from[mask*m+j])
+		mask &^= 1 << uint(j)
+		j = prev
+	}
+	return newTour(d, order), nil
+}
+
+// SolveTSP finds a good tour starting at waypoint 0. Small problems (up to
+// solveExactLimit waypoints) are solved exactly with HeldKarp. Otherwise nearest
+// neighbour tours from the first waypoint and from restarts randomly chosen others
+// (plus a Christofides tour, if the distances are symmetric) are improved with TwoOpt
+// and OrOpt, and the best is kept. The seed makes the result repeatable
+func SolveTSP(d DistanceMatrix, restarts int, seed int64) Tour {
+	if len(d) <= solveExactLimit {
+		t, _ := HeldKarp(d)
+		return t
+	}
+
+	improve := func(t Tour) Tour {
+		for {
+			before := t.Cost
+			t = OrOpt(d, TwoOpt(d, t))
+			if t.Cost >= before-1e-9 {
+				return t
+			}
+		}
+	}
+
+	candidates := []Tour{NearestNeighborTour(d, 0)}
+	if d.symmetric() {
+		candidates = append(candidates, ChristofidesTour(d))
+	}
+	rng := rand.New(rand.NewSource(seed))
+	for i := 0; i < restarts; i++ {
+		candidates = append(candidates, NearestNeighborTour(d, rng.Intn(len(d))).rotate(0))
+	}
+
+	best := Tour{Cost: math.Inf(1)}
+	for _, c := range candidates {
+		if t := improve(c.rotate(0)); t.Cost < best.Cost {
+			best = t
+		}
+	}
+	return best
+}
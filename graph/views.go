@@ -0,0 +1,388 @@
+package graph
+
+// The graph views below wrap a weighted digraph rather than copying it, and keep nothing
+// between calls: they work out their edges and weights from the underlying graph, and
+// from their filter or weight function, whenever they are asked, so they always match
+// the graph as it is now. The searches ask for one vertex's edges or one edge's weight
+// at a time, through EdgeLister and EdgeWeigher, which a view answers without building
+// anything. Edges and Weights have to build a whole new map on each call, so code that
+// uses them should ask once rather than at every step. A reversed graph has to look
+// through every edge to find those into a vertex, so a graph that is searched in reverse
+// many times is better copied with NewCSRGraph(Reverse(graph))
+
+// EdgeLister is a graph that can list the edges out of one vertex without building its
+// whole edge map. The searches use it when a graph has it
+type EdgeLister interface {
+	EdgesFrom(v Vertex) []Edge
+}
+
+// EdgeWeigher is a weighted digraph that can give the weight of one edge without
+// building its whole weight map. Relax uses it when a graph has it
+type EdgeWeigher interface {
+	Weight(edge Edge) float32
+}
+
+// edgesFrom returns the edges out of v, using EdgesFrom if graph has it
+func edgesFrom(graph DirectedGraph, v Vertex) []Edge {
+	if el, ok := graph.(EdgeLister); ok {
+		return el.EdgesFrom(v)
+	}
+	return graph.Edges()[v]
+}
+
+// edgeWeight returns the weight of edge, using Weight if graph has it
+func edgeWeight(graph WeightedDigraph, edge Edge) float32 {
+	if ew, ok := graph.(EdgeWeigher); ok {
+		return ew.Weight(edge)
+	}
+	return graph.Weights()[edge]
+}
+
+// hasEdge returns true if edge is one of graph's edges
+func hasEdge(graph DirectedGraph, edge Edge) bool {
+	for _, e := range edgesFrom(graph, edge.From()) {
+		if e == edge {
+			return true
+		}
+	}
+	return false
+}
+
+// ReversedEdge is an edge of a reversed graph, it goes the opposite way to the
+// underlying graph's Edge that it wraps
+type ReversedEdge struct {
+	Edge
+}
+
+func (re ReversedEdge) From() Vertex {
+	return re.Edge.To()
+}
+
+func (re ReversedEdge) To() Vertex {
+	return re.Edge.From()
+}
+
+type reverseView struct {
+	graph WeightedDigraph
+}
+
+// Reverse is a view of graph with every edge flipped, each edge is a ReversedEdge
+// of the graph's edge and has the same weight
+func Reverse(graph WeightedDigraph) WeightedDigraph {
+	return &reverseView{graph: graph}
+}
+
+func (rv *reverseView) Vertices() []Vertex {
+	return rv.graph.Vertices()
+}
+
+func (rv *reverseView) Edges() map[Vertex][]Edge {
+	edges := make(map[Vertex][]Edge)
+	for _, u := range rv.graph.Vertices() {
+		for _, e := range edgesFrom(rv.graph, u) {
+			edges[e.To()] = append(edges[e.To()], ReversedEdge{e})
+		}
+	}
+	return edges
+}
+
+// EdgesFrom returns the reversed edges into v, looking through all of the graph's edges
+func (rv *reverseView) EdgesFrom(v Vertex) []Edge {
+	edges := make([]Edge, 0)
+	for _, u := range rv.graph.Vertices() {
+		for _, e := range edgesFrom(rv.graph, u) {
+			if e.To() == v {
+				edges = append(edges, ReversedEdge{e})
+			}
+		}
+	}
+	return edges
+}
+
+func (rv *reverseView) Weights() map[Edge]float32 {
+	weights := make(map[Edge]float32)
+	for e, w := range rv.graph.Weights() {
+		weights[ReversedEdge{e}] = w
+	}
+	return weights
+}
+
+func (rv *reverseView) Weight(edge Edge) float32 {
+	re, ok := edge.(ReversedEdge)
+	if !ok {
+		return 0
+	}
+	return edgeWeight(rv.graph, re.Edge)
+}
+
+func (rv *reverseView) AddEdge(from, to Vertex) {
+	rv.graph.AddEdge(to, from)
+}
+
+// RemoveEdge removes the graph's edge behind a ReversedEdge, and panics for any other edge
+func (rv *reverseView) RemoveEdge(edge Edge) {
+	re, ok := edge.(ReversedEdge)
+	if !ok {
+		panic("cannot remove an edge that isn't a ReversedEdge from a reversed graph")
+	}
+	rv.graph.RemoveEdge(re.Edge)
+}
+
+type subgraphView struct {
+	graph    WeightedDigraph
+	vertices []Vertex
+	in       map[Vertex]bool
+}
+
+// InducedSubgraph is a view of the given vertices of graph and the edges between them
+func InducedSubgraph(graph WeightedDigraph, vertices []Vertex) WeightedDigraph {
+	sv := subgraphView{
+		graph:    graph,
+		vertices: vertices,
+		in:       make(map[Vertex]bool),
+	}
+	for _, v := range vertices {
+		sv.in[v] = true
+	}
+	return &sv
+}
+
+func (sv *subgraphView) Vertices() []Vertex {
+	return sv.vertices
+}
+
+func (sv *subgraphView) Edges() map[Vertex][]Edge {
+	edges := make(map[Vertex][]Edge)
+	for _, v := range sv.vertices {
+		edges[v] = sv.EdgesFrom(v)
+	}
+	return edges
+}
+
+func (sv *subgraphView) EdgesFrom(v Vertex) []Edge {
+	if !sv.in[v] {
+		return nil
+	}
+	all := edgesFrom(sv.graph, v)
+	kept := make([]Edge, 0, len(all))
+	for _, e := range all {
+		if sv.in[e.To()] {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// Weights returns the underlying graph's weights, which include the subgraph's
+func (sv *subgraphView) Weights() map[Edge]float32 {
+	return sv.graph.Weights()
+}
+
+func (sv *subgraphView) Weight(edge Edge) float32 {
+	return edgeWeight(sv.graph, edge)
+}
+
+func (sv *subgraphView) AddEdge(from, to Vertex) {
+	if !sv.in[from] || !sv.in[to] {
+		panic("vertex not in subgraph")
+	}
+	sv.graph.AddEdge(from, to)
+}
+
+func (sv *subgraphView) RemoveEdge(edge Edge) {
+	sv.graph.RemoveEdge(edge)
+}
+
+type filterView struct {
+	graph WeightedDigraph
+	keep  func(Edge) bool
+}
+
+// FilterEdges is a view of graph with only the edges that keep returns true for. keep is
+// called each time an edge is looked at, so a filter on changing state, such as which
+// doors are locked, always reflects the state as it is
+func FilterEdges(graph WeightedDigraph, keep func(Edge) bool) WeightedDigraph {
+	return &filterView{graph: graph, keep: keep}
+}
+
+func (fv *filterView) Vertices() []Vertex {
+	return fv.graph.Vertices()
+}
+
+func (fv *filterView) Edges() map[Vertex][]Edge {
+	edges := make(map[Vertex][]Edge)
+	for v, es := range fv.graph.Edges() {
+		edges[v] = fv.filter(es)
+	}
+	return edges
+}
+
+func (fv *filterView) EdgesFrom(v Vertex) []Edge {
+	return fv.filter(edgesFrom(fv.graph, v))
+}
+
+func (fv *filterView) filter(edges []Edge) []Edge {
+	kept := make([]Edge, 0, len(edges))
+	for _, e := range edges {
+		if fv.keep(e) {
+			kept = append(kept, e)
+		}
+	}
+	return kept
+}
+
+// Weights returns the underlying graph's weights, which include the filtered out edges
+func (fv *filterView) Weights() map[Edge]float32 {
+	return fv.graph.Weights()
+}
+
+func (fv *filterView) Weight(edge Edge) float32 {
+	return edgeWeight(fv.graph, edge)
+}
+
+func (fv *filterView) AddEdge(from, to Vertex) {
+	fv.graph.AddEdge(from, to)
+}
+
+func (fv *filterView) RemoveEdge(edge Edge) {
+	fv.graph.RemoveEdge(edge)
+}
+
+type reweightView struct {
+	graph  WeightedDigraph
+	weight func(Edge, float32) float32
+}
+
+// ReweightEdges is a view of graph where each edge's weight is given by weight, which
+// is passed the edge and its weight in graph. As with FilterEdges, weight is called each
+// time a weight is looked up
+func ReweightEdges(graph WeightedDigraph, weight func(Edge, float32) float32) WeightedDigraph {
+	return &reweightView{graph: graph, weight: weight}
+}
+
+func (rw *reweightView) Vertices() []Vertex {
+	return rw.graph.Vertices()
+}
+
+func (rw *reweightView) Edges() map[Vertex][]Edge {
+	return rw.graph.Edges()
+}
+
+func (rw *reweightView) EdgesFrom(v Vertex) []Edge {
+	return edgesFrom(rw.graph, v)
+}
+
+func (rw *reweightView) Weights() map[Edge]float32 {
+	weights := make(map[Edge]float32)
+	for e, w := range rw.graph.Weights() {
+		weights[e] = rw.weight(e, w)
+	}
+	return weights
+}
+
+func (rw *reweightView) Weight(edge Edge) float32 {
+	return rw.weight(edge, edgeWeight(rw.graph, edge))
+}
+
+func (rw *reweightView) AddEdge(from, to Vertex) {
+	rw.graph.AddEdge(from, to)
+}
+
+func (rw *reweightView) RemoveEdge(edge Edge) {
+	rw.graph.RemoveEdge(edge)
+}
+
+type unionView struct {
+	graphs []WeightedDigraph
+}
+
+// Union is a view of all the vertices and edges of the given graphs. An edge found in
+// more than one graph appears once, with its weight from the last of them
+func Union(graphs ...WeightedDigraph) WeightedDigraph {
+	return &unionView{graphs: graphs}
+}
+
+func (uv *unionView) Vertices() []Vertex {
+	seen := make(map[Vertex]bool)
+	verts := make([]Vertex, 0)
+	for _, g := range uv.graphs {
+		for _, v := range g.Vertices() {
+			if !seen[v] {
+				seen[v] = true
+				verts = append(verts, v)
+			}
+		}
+	}
+	return verts
+}
+
+func (uv *unionView) Edges() map[Vertex][]Edge {
+	edges := make(map[Vertex][]Edge)
+	seen := make(map[Edge]bool)
+	for _, g := range uv.graphs {
+		for v, es := range g.Edges() {
+			if _, ok := edges[v]; !ok {
+				edges[v] = make([]Edge, 0, len(es))
+			}
+			for _, e := range es {
+				if !seen[e] {
+					seen[e] = true
+					edges[v] = append(edges[v], e)
+				}
+			}
+		}
+	}
+	return edges
+}
+
+func (uv *unionView) EdgesFrom(v Vertex) []Edge {
+	edges := make([]Edge, 0)
+	for i, g := range uv.graphs {
+		for _, e := range edgesFrom(g, v) {
+			seen := false
+			for _, earlier := range uv.graphs[:i] {
+				if hasEdge(earlier, e) {
+					seen = true
+					break
+				}
+			}
+			if !seen {
+				edges = append(edges, e)
+			}
+		}
+	}
+	return edges
+}
+
+func (uv *unionView) Weights() map[Edge]float32 {
+	weights := make(map[Edge]float32)
+	for _, g := range uv.graphs {
+		for e, w := range g.Weights() {
+			weights[e] = w
+		}
+	}
+	return weights
+}
+
+func (uv *unionView) Weight(edge Edge) float32 {
+	for i := len(uv.graphs) - 1; i >= 0; i-- {
+		if hasEdge(uv.graphs[i], edge) {
+			return edgeWeight(uv.graphs[i], edge)
+		}
+	}
+	return 0
+}
+
+// AddEdge adds the edge to the first of the graphs
+func (uv *unionView) AddEdge(from, to Vertex) {
+	uv.graphs[0].AddEdge(from, to)
+}
+
+// RemoveEdge removes the edge from every graph it is in
+func (uv *unionView) RemoveEdge(edge Edge) {
+	for _, g := range uv.graphs {
+		if hasEdge(g, edge) {
+			g.RemoveEdge(edge)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package graph
+
+import (
+	"testing"
+)
+
+func TestFilterEdgesFollowsState(t *testing.T) {
+	// a corridor 0-1-2 with a door between 1 and 2, and a longer way round through 3
+	g := newTestGraph(0, 1, 2, 3)
+	g.addUndirectedEdge(0, 1, 1)
+	g.addUndirectedEdge(1, 2, 1)
+	g.addUndirectedEdge(0, 3, 2)
+	g.addUndirectedEdge(3, 2, 2)
+	locked := false
+	open := FilterEdges(g, func(e Edge) bool {
+		return !locked || e.From() != 1 || e.To() != 2
+	})
+
+	if cost := Dijkstra(open, 0)[2].ShortestEstimateFromSource(); cost != 2 {
+		t.Errorf("cost to 2 with the door open is %f, expected 2", cost)
+	}
+	locked = true
+	if cost := Dijkstra(open, 0)[2].ShortestEstimateFromSource(); cost != 4 {
+		t.Errorf("cost to 2 with the door locked is %f, expected 4", cost)
+	}
+	if d := BreadthFirstSearch(open, 1)[2].Distance(); d != 3 {
+		t.Errorf("distance from 1 to 2 with the door locked is %d, expected 3", d)
+	}
+
+	// changes made straight to the graph show through the view
+	g.addWeightedEdge(0, 2, 1)
+	if cost := Dijkstra(open, 0)[2].ShortestEstimateFromSource(); cost != 1 {
+		t.Errorf("cost to 2 after adding an edge to the graph is %f, expected 1", cost)
+	}
+}
+
+func TestReverse(t *testing.T) {
+	g := newTestGraph(0, 1, 2)
+	e := g.addWeightedEdge(0, 1, 3)
+	g.addWeightedEdge(1, 2, 4)
+	rv := Reverse(g)
+
+	if cost := Dijkstra(rv, 2)[0].ShortestEstimateFromSource(); cost != 7 {
+		t.Errorf("cost from 2 to 0 in the reversed graph is %f, expected 7", cost)
+	}
+	if w := rv.Weights()[ReversedEdge{e}]; w != 3 {
+		t.Errorf("reversed edge weighs %f, expected 3", w)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("removing an edge that isn't a ReversedEdge didn't panic")
+		}
+	}()
+	rv.RemoveEdge(e)
+}
@@ -0,0 +1,286 @@
+package navmesh
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/DaJobat/gogve/graph"
+	"github.com/DaJobat/gogve/util"
+)
+
+var (
+	ErrNotOnMesh = fmt.Errorf("point is not on the navigation mesh")
+	ErrNoPath    = fmt.Errorf("no path between points")
+)
+
+// portal is the edge shared by two neighbouring polygons, left and right
+// as seen when walking through it from one polygon into the next
+type portal struct {
+	left  util.FVec
+	right util.FVec
+}
+
+func (p portal) width() float64 {
+	return distance(p.left, p.right)
+}
+
+// NavMesh is a mesh of convex polygons covering the walkable area of a map. It is a
+// weighted digraph with a vertex for each polygon and an edge each way between
+// polygons that share an edge, weighted by the distance between their centroids
+type NavMesh struct {
+	polygons []*Polygon
+	edges    map[graph.Vertex][]graph.Edge
+	weights  map[graph.Edge]float32
+	portals  map[graph.Edge]portal
+}
+
+// NewNavMesh builds a navigation mesh from walkable polygons. Convex polygons are
+// kept whole, others are split into triangles. Polygons are joined wherever they
+// share a whole edge, so neighbouring polygons should meet vertex to vertex
+func NewNavMesh(walkable [][]util.FVec) *NavMesh {
+	m := NavMesh{
+		polygons: make([]*Polygon, 0, len(walkable)),
+		edges:    make(map[graph.Vertex][]graph.Edge),
+		weights:  make(map[graph.Edge]float32),
+		portals:  make(map[graph.Edge]portal),
+	}
+
+	for _, points := range walkable {
+		if len(points) < 3 {
+			panic("walkable polygon needs at least 3 points")
+		}
+		points = anticlockwise(points)
+		if isConvex(points) {
+			m.addPolygon(points)
+			continue
+		}
+		for _, tri := range triangulate(points) {
+			m.addPolygon(tri)
+		}
+	}
+
+	m.connect()
+	return &m
+}
+
+func (m *NavMesh) addPolygon(points []util.FVec) {
+	p := newPolygon(len(m.polygons), points)
+	m.polygons = append(m.polygons, p)
+	m.edges[p] = make([]graph.Edge, 0)
+}
+
+type pointKey [2]int64
+
+func keyOf(pt util.FVec) pointKey {
+	return pointKey{
+		int64(math.Round(pt.X() / pointFuzz)),
+		int64(math.Round(pt.Y() / pointFuzz)),
+	}
+}
+
+// connect joins every pair of polygons that share an edge
+func (m *NavMesh) connect() {
+	type sideOwner struct {
+		polygon *Polygon
+		side    int
+	}
+	sides := make(map[[2]pointKey][]sideOwner)
+	for _, p := range m.polygons {
+		for i := range p.points {
+			a, b := keyOf(p.points[i]), keyOf(p.points[(i+1)%len(p.points)])
+			sides[[2]pointKey{a, b}] = append(sides[[2]pointKey{a, b}], sideOwner{p, i})
+		}
+	}
+
+	for _, p := range m.polygons {
+		for i := range p.points {
+			a, b := p.points[i], p.points[(i+1)%len(p.points)]
+			// both polygons are anticlockwise, so a neighbour has this side running the other way
+			for _, other := range sides[[2]pointKey{keyOf(b), keyOf(a)}] {
+				if other.polygon == p {
+					continue
+				}
+				e := graph.NewEdge(p, other.polygon)
+				m.edges[p] = append(m.edges[p], e)
+				m.weights[e] = float32(distance(p.centroid, other.polygon.centroid))
+				// leaving an anticlockwise polygon, the side's start is on the right
+				m.portals[e] = portal{left: b, right: a}
+			}
+		}
+	}
+}
+
+func (m *NavMesh) Polygons() []*Polygon {
+	return m.polygons
+}
+
+func (m *NavMesh) Vertices() []graph.Vertex {
+	verts := make([]graph.Vertex, len(m.polygons))
+	for i, p := range m.polygons {
+		verts[i] = p
+	}
+	return verts
+}
+
+func (m *NavMesh) Edges() map[graph.Vertex][]graph.Edge {
+	return m.edges
+}
+
+func (m *NavMesh) Weights() map[graph.Edge]float32 {
+	return m.weights
+}
+
+// AddEdge panics, the edges of a navigation mesh come from the shape of its polygons
+func (m *NavMesh) AddEdge(from, to graph.Vertex) {
+	panic("cannot add edges to a navigation mesh")
+}
+
+// RemoveEdge closes the portal between two polygons, e.g. for a locked door
+func (m *NavMesh) RemoveEdge(edge graph.Edge) {
+	es := m.edges[edge.From()]
+	for i, e := range es {
+		if e == edge {
+			m.edges[edge.From()] = append(es[:i:i], es[i+1:]...)
+			break
+		}
+	}
+	delete(m.weights, edge)
+	delete(m.portals, edge)
+}
+
+// Locate returns the polygon containing pt, or nil if it is off the mesh
+func (m *NavMesh) Locate(pt util.FVec) *Polygon {
+	for _, p := range m.polygons {
+		if p.Contains(pt) {
+			return p
+		}
+	}
+	return nil
+}
+
+// FindPath finds a path from start to end across the mesh, for an agent with the given
+// radius. The polygons are searched with AStar, skipping portals too narrow for the
+// agent, and the corridor of polygons found is pulled tight with the funnel algorithm.
+// The returned path includes start and end
+func (m *NavMesh) FindPath(start, end util.FVec, radius float64) ([]util.FVec, error) {
+	from, to := m.Locate(start), m.Locate(end)
+	if from == nil || to == nil {
+		return nil, ErrNotOnMesh
+	}
+	if from == to {
+		return []util.FVec{start, end}, nil
+	}
+
+	var search graph.WeightedDigraph = m
+	if radius > 0 {
+		search = &passableMesh{NavMesh: m, edges: m.passableEdges(radius)}
+	}
+
+	attrs := graph.AStar(search, from, to)
+	corridor, ok := graph.PathFromAttributes(search, attrs.ToRelaxableAttributes(), from, to)
+	if !ok {
+		return nil, ErrNoPath
+	}
+
+	portals := make([]portal, 0, len(corridor.Edges)+2)
+	portals = append(portals, portal{left: start, right: start})
+	for _, e := range corridor.Edges {
+		portals = append(portals, m.portals[e].shrink(radius))
+	}
+	portals = append(portals, portal{left: end, right: end})
+
+	return stringPull(portals), nil
+}
+
+// passableMesh is a navigation mesh with only the portals wide enough for an agent
+type passableMesh struct {
+	*NavMesh
+	edges map[graph.Vertex][]graph.Edge
+}
+
+func (pm *passableMesh) Edges() map[graph.Vertex][]graph.Edge {
+	return pm.edges
+}
+
+func (m *NavMesh) passableEdges(radius float64) map[graph.Vertex][]graph.Edge {
+	edges := make(map[graph.Vertex][]graph.Edge)
+	for v, es := range m.edges {
+		kept := make([]graph.Edge, 0, len(es))
+		for _, e := range es {
+			if m.portals[e].width() >= 2*radius {
+				kept = append(kept, e)
+			}
+		}
+		edges[v] = kept
+	}
+	return edges
+}
+
+// shrink moves both ends of the portal in by radius, so an agent of that radius
+// walking through it doesn't clip the corners
+func (p portal) shrink(radius float64) portal {
+	w := p.width()
+	if radius <= 0 || w == 0 {
+		return p
+	}
+	t := math.Min(radius/w, 0.5)
+	dx, dy := p.right.X()-p.left.X(), p.right.Y()-p.left.Y()
+	return portal{
+		left:  util.NewFVec2(p.left.X()+dx*t, p.left.Y()+dy*t),
+		right: util.NewFVec2(p.right.X()-dx*t, p.right.Y()-dy*t),
+	}
+}
+
+// stringPull runs the simple stupid funnel algorithm over a list of portals, the first and
+// last of which are the start and end points. The funnel is narrowed portal by portal, and
+// whenever one side would cross over the other that corner is added to the path and
+// becomes the new apex of the funnel
+func stringPull(portals []portal) []util.FVec {
+	path := []util.FVec{portals[0].left}
+	apex, left, right := portals[0].left, portals[0].left, portals[0].right
+	apexIndex, leftIndex, rightIndex := 0, 0, 0
+
+	for i := 1; i < len(portals); i++ {
+		l, r := portals[i].left, portals[i].right
+
+		// try to narrow the right side of the funnel
+		if triarea2(apex, right, r) >= 0 {
+			if samePoint(apex, right) || triarea2(apex, left, r) < 0 {
+				right = r
+				rightIndex = i
+			} else {
+				// right crossed over left, so left is a corner on the path
+				path = appendPoint(path, left)
+				apex, apexIndex = left, leftIndex
+				left, right = apex, apex
+				leftIndex, rightIndex = apexIndex, apexIndex
+				i = apexIndex
+				continue
+			}
+		}
+
+		// try to narrow the left side of the funnel
+		if triarea2(apex, left, l) <= 0 {
+			if samePoint(apex, left) || triarea2(apex, right, l) > 0 {
+				left = l
+				leftIndex = i
+			} else {
+				path = appendPoint(path, right)
+				apex, apexIndex = right, rightIndex
+				left, right = apex, apex
+				leftIndex, rightIndex = apexIndex, apexIndex
+				i = apexIndex
+				continue
+			}
+		}
+	}
+
+	return appendPoint(path, portals[len(portals)-1].left)
+}
+
+func appendPoint(path []util.FVec, pt util.FVec) []util.FVec {
+	if samePoint(path[len(path)-1], pt) {
+		return path
+	}
+	return append(path, pt)
+}
@@ -0,0 +1,81 @@
+package navmesh
+
+import (
+	"math"
+	"testing"
+
+	"github.com/DaJobat/gogve/util"
+)
+
+func points(coords ...float64) []util.FVec {
+	ps := make([]util.FVec, 0, len(coords)/2)
+	for i := 0; i+1 < len(coords); i += 2 {
+		ps = append(ps, util.NewFVec2(coords[i], coords[i+1]))
+	}
+	return ps
+}
+
+func TestFindPathCorridorCorner(t *testing.T) {
+	// an L shaped corridor, the only way round is past the inner corner at (8,2)
+	mesh := NewNavMesh([][]util.FVec{points(0, 0, 10, 0, 10, 10, 8, 10, 8, 2, 0, 2)})
+
+	path, err := mesh.FindPath(util.NewFVec2(1, 1), util.NewFVec2(9, 9), 0)
+	if err != nil {
+		t.Fatalf("FindPath errors on a connected corridor: %v", err)
+	}
+	if len(path) != 3 {
+		t.Fatalf("path has %d points, want start, corner and end: %v", len(path), path)
+	}
+	if !samePoint(path[0], util.NewFVec2(1, 1)) || !samePoint(path[2], util.NewFVec2(9, 9)) {
+		t.Errorf("path doesn't run from start to end: %v", path)
+	}
+	if !samePoint(path[1], util.NewFVec2(8, 2)) {
+		t.Errorf("path turns at %v, want the inner corner (8,2)", path[1])
+	}
+}
+
+func TestFindPathSamePolygon(t *testing.T) {
+	mesh := NewNavMesh([][]util.FVec{points(0, 0, 10, 0, 10, 10, 0, 10)})
+
+	path, err := mesh.FindPath(util.NewFVec2(1, 1), util.NewFVec2(9, 8), 0)
+	if err != nil {
+		t.Fatalf("FindPath errors within one polygon: %v", err)
+	}
+	if len(path) != 2 || !samePoint(path[0], util.NewFVec2(1, 1)) || !samePoint(path[1], util.NewFVec2(9, 8)) {
+		t.Errorf("path within one polygon isn't a straight line from start to end: %v", path)
+	}
+}
+
+func TestFindPathRadius(t *testing.T) {
+	// a corridor one unit wide, made of two squares joined by a portal from (4,0) to (4,1)
+	mesh := NewNavMesh([][]util.FVec{
+		points(0, 0, 4, 0, 4, 1, 0, 1),
+		points(4, 0, 8, 0, 8, 1, 4, 1),
+	})
+	start, end := util.NewFVec2(1, 0.5), util.NewFVec2(7, 0.5)
+
+	if _, err := mesh.FindPath(start, end, 0.6); err != ErrNoPath {
+		t.Errorf("an agent wider than the corridor found a path, err %v", err)
+	}
+	path, err := mesh.FindPath(start, end, 0.4)
+	if err != nil {
+		t.Fatalf("FindPath errors for an agent narrower than the corridor: %v", err)
+	}
+	if len(path) != 2 || !samePoint(path[0], start) || !samePoint(path[1], end) {
+		t.Errorf("path along a straight corridor isn't a straight line: %v", path)
+	}
+
+	// turning the corner of the L shaped corridor, the agent keeps its radius clear of
+	// the inner corner
+	mesh = NewNavMesh([][]util.FVec{points(0, 0, 10, 0, 10, 10, 8, 10, 8, 2, 0, 2)})
+	path, err = mesh.FindPath(util.NewFVec2(1, 1), util.NewFVec2(9, 9), 0.5)
+	if err != nil {
+		t.Fatalf("FindPath errors on a connected corridor: %v", err)
+	}
+	if len(path) != 3 {
+		t.Fatalf("path has %d points, want start, corner and end: %v", len(path), path)
+	}
+	if d := distance(path[1], util.NewFVec2(8, 2)); math.Abs(d-0.5) > 1e-9 {
+		t.Errorf("path turns %f from the inner corner, want 0.5: %v", d, path)
+	}
+}
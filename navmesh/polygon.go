@@ -0,0 +1,147 @@
+package navmesh
+
+import (
+	"math"
+
+	"github.com/DaJobat/gogve/graph"
+	"github.com/DaJobat/gogve/util"
+)
+
+// Polygon is a convex cell of a navigation mesh, its points are wound anticlockwise
+type Polygon struct {
+	index    int
+	points   []util.FVec
+	centroid util.FVec
+}
+
+func newPolygon(index int, points []util.FVec) *Polygon {
+	p := Polygon{
+		index:  index,
+		points: points,
+	}
+
+	var cx, cy float64
+	for _, pt := range points {
+		cx += pt.X()
+		cy += pt.Y()
+	}
+	p.centroid = util.NewFVec2(cx/float64(len(points)), cy/float64(len(points)))
+
+	return &p
+}
+
+func (p *Polygon) Index() int {
+	return p.index
+}
+
+func (p *Polygon) Points() []util.FVec {
+	return p.points
+}
+
+func (p *Polygon) Centroid() util.FVec {
+	return p.centroid
+}
+
+// Contains returns true if pt is inside or on the boundary of the polygon
+func (p *Polygon) Contains(pt util.FVec) bool {
+	for i := range p.points {
+		if triarea2(p.points[i], p.points[(i+1)%len(p.points)], pt) < -pointFuzz {
+			return false
+		}
+	}
+	return true
+}
+
+// EstimatedDistance is the straight line distance between polygon centroids
+func (p *Polygon) EstimatedDistance(v graph.Vertex) float32 {
+	return float32(distance(p.centroid, v.(*Polygon).centroid))
+}
+
+var pointFuzz float64 = 0.000001
+
+// triarea2 is twice the signed area of the triangle abc, positive if c is to the left of ab
+func triarea2(a, b, c util.FVec) float64 {
+	return util.Vec2CrossProduct(
+		util.NewFVec2(b.X()-a.X(), b.Y()-a.Y()),
+		util.NewFVec2(c.X()-a.X(), c.Y()-a.Y()),
+	)
+}
+
+func distance(a, b util.FVec) float64 {
+	return math.Hypot(b.X()-a.X(), b.Y()-a.Y())
+}
+
+func samePoint(a, b util.FVec) bool {
+	return math.Abs(a.X()-b.X()) < pointFuzz && math.Abs(a.Y()-b.Y()) < pointFuzz
+}
+
+func signedArea(points []util.FVec) float64 {
+	area := 0.0
+	for i := range points {
+		area += util.Vec2CrossProduct(points[i], points[(i+1)%len(points)])
+	}
+	return area / 2
+}
+
+// anticlockwise returns the points wound anticlockwise, copying them if they need reversing
+func anticlockwise(points []util.FVec) []util.FVec {
+	if signedArea(points) >= 0 {
+		return points
+	}
+	reversed := make([]util.FVec, len(points))
+	for i, pt := range points {
+		reversed[len(points)-1-i] = pt
+	}
+	return reversed
+}
+
+func isConvex(points []util.FVec) bool {
+	n := len(points)
+	for i := range points {
+		if triarea2(points[i], points[(i+1)%n], points[(i+2)%n]) < -pointFuzz {
+			return false
+		}
+	}
+	return true
+}
+
+// triangulate splits a simple anticlockwise polygon into triangles by ear clipping
+func triangulate(points []util.FVec) [][]util.FVec {
+	remaining := make([]util.FVec, len(points))
+	copy(remaining, points)
+	triangles := make([][]util.FVec, 0, len(points)-2)
+
+	for len(remaining) > 3 {
+		n := len(remaining)
+		clipped := false
+		for i := 0; i < n; i++ {
+			prev, cur, next := remaining[(i+n-1)%n], remaining[i], remaining[(i+1)%n]
+			if triarea2(prev, cur, next) <= pointFuzz {
+				// reflex or flat, can't be an ear
+				continue
+			}
+
+			ear := true
+			for _, pt := range remaining {
+				if pt == prev || pt == cur || pt == next {
+					continue
+				}
+				if triarea2(prev, cur, pt) >= 0 && triarea2(cur, next, pt) >= 0 && triarea2(next, prev, pt) >= 0 {
+					ear = false
+					break
+				}
+			}
+			if ear {
+				triangles = append(triangles, []util.FVec{prev, cur, next})
+				remaining = append(remaining[:i:i], remaining[i+1:]...)
+				clipped = true
+				break
+			}
+		}
+		if !clipped {
+			panic("polygon is not simple, cannot triangulate")
+		}
+	}
+
+	return append(triangles, remaining)
+}
@@ -0,0 +1,158 @@
+package priorityqueue
+
+// daryHeap is an indexed d-ary heap stored in slices, with a map from each key
+// to its position so keys can be found and moved
+type daryHeap struct {
+	d          int
+	less       Less
+	keys       []interface{}
+	priorities []float64
+	index      map[interface{}]int
+}
+
+// NewBinaryHeap returns an indexed binary heap
+func NewBinaryHeap(less Less) Queue {
+	return NewDaryHeap(2, less)
+}
+
+// NewDaryHeap returns an indexed heap where every node has d children. A wider heap is
+// shallower, so DecreaseKey (and Push) is cheaper and Pop dearer, which suits searches
+// that decrease keys far more often than they pop. 4 is a good choice for these
+func NewDaryHeap(d int, less Less) Queue {
+	if d < 2 {
+		panic("heap needs at least 2 children per node")
+	}
+	return &daryHeap{
+		d:          d,
+		less:       less,
+		keys:       make([]interface{}, 0),
+		priorities: make([]float64, 0),
+		index:      make(map[interface{}]int),
+	}
+}
+
+func (h *daryHeap) Len() int {
+	return len(h.keys)
+}
+
+func (h *daryHeap) Contains(key interface{}) bool {
+	_, ok := h.index[key]
+	return ok
+}
+
+func (h *daryHeap) Priority(key interface{}) (float64, bool) {
+	i, ok := h.index[key]
+	if !ok {
+		return 0, false
+	}
+	return h.priorities[i], true
+}
+
+func (h *daryHeap) Push(key interface{}, priority float64) {
+	if h.Contains(key) {
+		panic("key already in queue")
+	}
+	h.keys = append(h.keys, key)
+	h.priorities = append(h.priorities, priority)
+	h.index[key] = len(h.keys) - 1
+	h.up(len(h.keys) - 1)
+}
+
+func (h *daryHeap) Peek() (interface{}, float64) {
+	if len(h.keys) == 0 {
+		panic("queue is empty")
+	}
+	return h.keys[0], h.priorities[0]
+}
+
+func (h *daryHeap) Pop() (interface{}, float64) {
+	key, priority := h.Peek()
+	h.removeAt(0)
+	return key, priority
+}
+
+func (h *daryHeap) DecreaseKey(key interface{}, priority float64) bool {
+	i, ok := h.index[key]
+	if !ok || !h.less(priority, h.priorities[i]) {
+		return false
+	}
+	h.priorities[i] = priority
+	h.up(i)
+	return true
+}
+
+func (h *daryHeap) Update(key interface{}, priority float64) {
+	i, ok := h.index[key]
+	if !ok {
+		h.Push(key, priority)
+		return
+	}
+	h.priorities[i] = priority
+	h.down(h.up(i))
+}
+
+func (h *daryHeap) Remove(key interface{}) bool {
+	i, ok := h.index[key]
+	if !ok {
+		return false
+	}
+	h.removeAt(i)
+	return true
+}
+
+// removeAt moves the last item into position i and restores the heap
+func (h *daryHeap) removeAt(i int) {
+	last := len(h.keys) - 1
+	delete(h.index, h.keys[i])
+	if i != last {
+		h.keys[i] = h.keys[last]
+		h.priorities[i] = h.priorities[last]
+		h.index[h.keys[i]] = i
+	}
+	h.keys[last] = nil
+	h.keys = h.keys[:last]
+	h.priorities = h.priorities[:last]
+	if i != last {
+		h.down(h.up(i))
+	}
+}
+
+func (h *daryHeap) swap(i, j int) {
+	h.keys[i], h.keys[j] = h.keys[j], h.keys[i]
+	h.priorities[i], h.priorities[j] = h.priorities[j], h.priorities[i]
+	h.index[h.keys[i]] = i
+	h.index[h.keys[j]] = j
+}
+
+// up moves the item at i towards the root until it's in order, returning where it ends up
+func (h *daryHeap) up(i int) int {
+	for i > 0 {
+		parent := (i - 1) / h.d
+		if !h.less(h.priorities[i], h.priorities[parent]) {
+			break
+		}
+		h.swap(i, parent)
+		i = parent
+	}
+	return i
+}
+
+func (h *daryHeap) down(i int) {
+	for {
+		first := h.d*i + 1
+		if first >= len(h.keys) {
+			return
+		}
+		best := first
+		for c := first + 1; c < first+h.d && c < len(h.keys); c++ {
+			if h.less(h.priorities[c], h.priorities[best]) {
+				best = c
+			}
+		}
+		if !h.less(h.priorities[best], h.priorities[i]) {
+			return
+		}
+		h.swap(i, best)
+		i = best
+	}
+}
@@ -0,0 +1,167 @@
+package priorityqueue
+
+type pairingNode struct {
+	key      interface{}
+	priority float64
+	child    *pairingNode // the first child
+	sibling  *pairingNode // the next sibling
+	prev     *pairingNode // the previous sibling, or the parent of a first child
+}
+
+// pairingHeap is a heap ordered tree where each node keeps its children as a linked
+// list. DecreaseKey cuts the node out and melds it back in at the root, which takes
+// constant time, with the cost of tidying up put off until the next Pop
+type pairingHeap struct {
+	less  Less
+	root  *pairingNode
+	index map[interface{}]*pairingNode
+}
+
+// NewPairingHeap returns an indexed pairing heap
+func NewPairingHeap(less Less) Queue {
+	return &pairingHeap{
+		less:  less,
+		index: make(map[interface{}]*pairingNode),
+	}
+}
+
+func (h *pairingHeap) Len() int {
+	return len(h.index)
+}
+
+func (h *pairingHeap) Contains(key interface{}) bool {
+	_, ok := h.index[key]
+	return ok
+}
+
+func (h *pairingHeap) Priority(key interface{}) (float64, bool) {
+	n, ok := h.index[key]
+	if !ok {
+		return 0, false
+	}
+	return n.priority, true
+}
+
+// meld joins two trees, the one with the lower priority root becomes the parent
+func (h *pairingHeap) meld(a, b *pairingNode) *pairingNode {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+	if h.less(b.priority, a.priority) {
+		a, b = b, a
+	}
+	b.prev = a
+	b.sibling = a.child
+	if a.child != nil {
+		a.child.prev = b
+	}
+	a.child = b
+	a.sibling = nil
+	a.prev = nil
+	return a
+}
+
+// mergePairs melds a list of siblings into one tree, pairing them off left to right
+// and then melding the pairs right to left
+func (h *pairingHeap) mergePairs(first *pairingNode) *pairingNode {
+	pairs := make([]*pairingNode, 0)
+	for first != nil {
+		a := first
+		b := a.sibling
+		if b == nil {
+			a.prev = nil
+			pairs = append(pairs, a)
+			break
+		}
+		first = b.sibling
+		a.sibling, a.prev = nil, nil
+		b.sibling, b.prev = nil, nil
+		pairs = append(pairs, h.meld(a, b))
+	}
+
+	var tree *pairingNode
+	for i := len(pairs) - 1; i >= 0; i-- {
+		tree = h.meld(pairs[i], tree)
+	}
+	return tree
+}
+
+func (h *pairingHeap) Push(key interface{}, priority float64) {
+	if h.Contains(key) {
+		panic("key already in queue")
+	}
+	n := &pairingNode{key: key, priority: priority}
+	h.index[key] = n
+	h.root = h.meld(h.root, n)
+}
+
+func (h *pairingHeap) Peek() (interface{}, float64) {
+	if h.root == nil {
+		panic("queue is empty")
+	}
+	return h.root.key, h.root.priority
+}
+
+func (h *pairingHeap) Pop() (interface{}, float64) {
+	key, priority := h.Peek()
+	delete(h.index, key)
+	h.root = h.mergePairs(h.root.child)
+	return key, priority
+}
+
+// cut detaches n, and the subtree under it, from its parent
+func (h *pairingHeap) cut(n *pairingNode) {
+	if n == h.root {
+		return
+	}
+	if n.prev.child == n {
+		n.prev.child = n.sibling
+	} else {
+		n.prev.sibling = n.sibling
+	}
+	if n.sibling != nil {
+		n.sibling.prev = n.prev
+	}
+	n.sibling = nil
+	n.prev = nil
+}
+
+func (h *pairingHeap) DecreaseKey(key interface{}, priority float64) bool {
+	n, ok := h.index[key]
+	if !ok || !h.less(priority, n.priority) {
+		return false
+	}
+	n.priority = priority
+	if n != h.root {
+		h.cut(n)
+		h.root = h.meld(h.root, n)
+	}
+	return true
+}
+
+func (h *pairingHeap) Update(key interface{}, priority float64) {
+	if h.DecreaseKey(key, priority) {
+		return
+	}
+	// moving back, so its children may now belong in front of it
+	h.Remove(key)
+	h.Push(key, priority)
+}
+
+func (h *pairingHeap) Remove(key interface{}) bool {
+	n, ok := h.index[key]
+	if !ok {
+		return false
+	}
+	delete(h.index, key)
+	if n == h.root {
+		h.root = h.mergePairs(n.child)
+		return true
+	}
+	h.cut(n)
+	h.root = h.meld(h.root, h.mergePairs(n.child))
+	return true
+}
@@ -0,0 +1,38 @@
+package priorityqueue
+
+// Queue is an indexed priority queue. Each key is in the queue at most once, and its
+// priority can be changed while it is queued, so searches can lower a vertex's cost
+// in place rather than pushing it again. Keys must be comparable, as map keys are
+type Queue interface {
+	// Push adds key with the given priority, it panics if key is already queued
+	Push(key interface{}, priority float64)
+	// Pop removes and returns the key at the front of the queue
+	Pop() (interface{}, float64)
+	// Peek returns the key at the front of the queue without removing it
+	Peek() (interface{}, float64)
+	// DecreaseKey moves key towards the front, lowering its priority in a min queue or
+	// raising it in a max queue. It returns false, changing nothing, if key isn't
+	// queued or the new priority wouldn't move it forward
+	DecreaseKey(key interface{}, priority float64) bool
+	// Update sets the priority of key, adding it if it isn't queued
+	Update(key interface{}, priority float64)
+	// Remove takes key out of the queue, returning false if it wasn't queued
+	Remove(key interface{}) bool
+	Contains(key interface{}) bool
+	// Priority returns the priority of key, or false if it isn't queued
+	Priority(key interface{}) (float64, bool)
+	Len() int
+}
+
+// Less decides the order of a queue, a is in front of b if Less(a, b)
+type Less func(a, b float64) bool
+
+// MinFirst orders a queue with the lowest priority at the front
+func MinFirst(a, b float64) bool {
+	return a < b
+}
+
+// MaxFirst orders a queue with the highest priority at the front
+func MaxFirst(a, b float64) bool {
+	return a > b
+}
@@ -0,0 +1,65 @@
+package priorityqueue
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestQueues(t *testing.T) {
+	queues := map[string]func(Less) Queue{
+		"binary": NewBinaryHeap,
+		"4-ary": func(less Less) Queue {
+			return NewDaryHeap(4, less)
+		},
+		"pairing": NewPairingHeap,
+	}
+	for name, newQueue := range queues {
+		for _, less := range []Less{MinFirst, MaxFirst} {
+			r := rand.New(rand.NewSource(1))
+			q := newQueue(less)
+			// expected holds the priority of every key that should be queued
+			expected := make(map[int]float64)
+			for step := 0; step < 5000; step++ {
+				key := r.Intn(200)
+				priority := float64(r.Intn(1000))
+				switch op := r.Intn(5); {
+				case op == 0 && !q.Contains(key):
+					q.Push(key, priority)
+					expected[key] = priority
+				case op == 1:
+					q.Update(key, priority)
+					expected[key] = priority
+				case op == 2:
+					old, ok := expected[key]
+					moved := q.DecreaseKey(key, priority)
+					if moved != (ok && less(priority, old)) {
+						t.Fatalf("%s: DecreaseKey of %d from %f to %f returned %t", name, key, old, priority, moved)
+					}
+					if moved {
+						expected[key] = priority
+					}
+				case op == 3:
+					_, ok := expected[key]
+					if q.Remove(key) != ok {
+						t.Fatalf("%s: Remove of %d returned %t", name, key, !ok)
+					}
+					delete(expected, key)
+				case op == 4 && q.Len() > 0:
+					key, priority := q.Pop()
+					for k, p := range expected {
+						if less(p, priority) {
+							t.Fatalf("%s: popped %v (%f) before %d (%f)", name, key, priority, k, p)
+						}
+					}
+					if expected[key.(int)] != priority {
+						t.Fatalf("%s: popped %v with priority %f, expected %f", name, key, priority, expected[key.(int)])
+					}
+					delete(expected, key.(int))
+				}
+				if q.Len() != len(expected) {
+					t.Fatalf("%s: length %d, expected %d", name, q.Len(), len(expected))
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package unionfind
+
+// Rollback is a union-find whose unions can be undone in the reverse of the order they
+// were made, as offline dynamic connectivity needs when it walks a segment tree of edge
+// lifetimes. Undoing rules out path compression, so finds take O(log n) from union by
+// size alone
+type Rollback struct {
+	parent  []int
+	size    []int
+	count   int
+	history []int // the root each union hung under another, -1 if it merged nothing
+}
+
+// NewRollback returns a rollback union-find of n elements, each in its own set
+func NewRollback(n int) *Rollback {
+	r := Rollback{
+		parent:  make([]int, n),
+		size:    make([]int, n),
+		count:   n,
+		history: make([]int, 0),
+	}
+	for i := range r.parent {
+		r.parent[i] = i
+		r.size[i] = 1
+	}
+	return &r
+}
+
+// Add adds a new element in a set of its own and returns it. Adding isn't undone by
+// a rollback
+func (r *Rollback) Add() int {
+	x := len(r.parent)
+	r.parent = append(r.parent, x)
+	r.size = append(r.size, 1)
+	r.count++
+	return x
+}
+
+// Len returns the number of elements
+func (r *Rollback) Len() int {
+	return len(r.parent)
+}
+
+// Count returns the number of disjoint sets
+func (r *Rollback) Count() int {
+	return r.count
+}
+
+// Find returns the root of the set x is in
+func (r *Rollback) Find(x int) int {
+	for r.parent[x] != x {
+		x = r.parent[x]
+	}
+	return x
+}
+
+// Union merges the sets a and b are in, returning false if they were already the same
+// set. Either way it is recorded, and is undone by one Undo
+func (r *Rollback) Union(a, b int) bool {
+	a, b = r.Find(a), r.Find(b)
+	if a == b {
+		r.history = append(r.history, -1)
+		return false
+	}
+	if r.size[a] < r.size[b] {
+		a, b = b, a
+	}
+	r.parent[b] = a
+	r.size[a] += r.size[b]
+	r.count--
+	r.history = append(r.history, b)
+	return true
+}
+
+// Connected returns true if a and b are in the same set
+func (r *Rollback) Connected(a, b int) bool {
+	return r.Find(a) == r.Find(b)
+}
+
+// Size returns the number of elements in the set x is in
+func (r *Rollback) Size(x int) int {
+	return r.size[r.Find(x)]
+}
+
+// Sets returns the members of every set, in order of their smallest member
+func (r *Rollback) Sets() [][]int {
+	return sets(len(r.parent), r.Find)
+}
+
+// Snapshot returns a marker for the current state, to roll back to later
+func (r *Rollback) Snapshot() int {
+	return len(r.history)
+}
+
+// Undo undoes the last union, returning false if there are none left
+func (r *Rollback) Undo() bool {
+	if len(r.history) == 0 {
+		return false
+	}
+	b := r.history[len(r.history)-1]
+	r.history = r.history[:len(r.history)-1]
+	if b < 0 {
+		return true
+	}
+	a := r.parent[b]
+	r.parent[b] = b
+	r.size[a] -= r.size[b]
+	r.count++
+	return true
+}
+
+// RollbackTo undoes every union made since snapshot was taken
+func (r *Rollback) RollbackTo(snapshot int) {
+	if snapshot < 0 || snapshot > len(r.history) {
+		panic("snapshot out of range")
+	}
+	for len(r.history) > snapshot {
+		r.Undo()
+	}
+}
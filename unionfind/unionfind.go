@@ -0,0 +1,103 @@
+package unionfind
+
+// UnionFind is a disjoint set forest over the elements 0 to Len()-1, which starts with
+// every element in a set of its own. Finds compress the path they take and unions hang
+// the smaller tree under the larger, so any sequence of operations takes nearly
+// constant time each
+type UnionFind struct {
+	parent []int
+	size   []int // the number of elements in each root's set
+	count  int
+}
+
+// New returns a union-find of n elements, each in its own set
+func New(n int) *UnionFind {
+	u := UnionFind{
+		parent: make([]int, n),
+		size:   make([]int, n),
+		count:  n,
+	}
+	for i := range u.parent {
+		u.parent[i] = i
+		u.size[i] = 1
+	}
+	return &u
+}
+
+// Add adds a new element in a set of its own and returns it
+func (u *UnionFind) Add() int {
+	x := len(u.parent)
+	u.parent = append(u.parent, x)
+	u.size = append(u.size, 1)
+	u.count++
+	return x
+}
+
+// Len returns the number of elements
+func (u *UnionFind) Len() int {
+	return len(u.parent)
+}
+
+// Count returns the number of disjoint sets
+func (u *UnionFind) Count() int {
+	return u.count
+}
+
+// Find returns the root of the set x is in, every member of a set has the same root
+func (u *UnionFind) Find(x int) int {
+	root := x
+	for u.parent[root] != root {
+		root = u.parent[root]
+	}
+	for u.parent[x] != root {
+		x, u.parent[x] = u.parent[x], root
+	}
+	return root
+}
+
+// Union merges the sets a and b are in, returning false if they were already the same set
+func (u *UnionFind) Union(a, b int) bool {
+	a, b = u.Find(a), u.Find(b)
+	if a == b {
+		return false
+	}
+	if u.size[a] < u.size[b] {
+		a, b = b, a
+	}
+	u.parent[b] = a
+	u.size[a] += u.size[b]
+	u.count--
+	return true
+}
+
+// Connected returns true if a and b are in the same set
+func (u *UnionFind) Connected(a, b int) bool {
+	return u.Find(a) == u.Find(b)
+}
+
+// Size returns the number of elements in the set x is in
+func (u *UnionFind) Size(x int) int {
+	return u.size[u.Find(x)]
+}
+
+// Sets returns the members of every set, in order of their smallest member
+func (u *UnionFind) Sets() [][]int {
+	return sets(len(u.parent), u.Find)
+}
+
+// sets groups the elements 0 to n-1 by root
+func sets(n int, find func(int) int) [][]int {
+	index := make(map[int]int)
+	out := make([][]int, 0)
+	for x := 0; x < n; x++ {
+		root := find(x)
+		i, ok := index[root]
+		if !ok {
+			i = len(out)
+			index[root] = i
+			out = append(out, make([]int, 0))
+		}
+		out[i] = append(out[i], x)
+	}
+	return out
+}
@@ -0,0 +1,91 @@
+package unionfind
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// labels is a slow disjoint set to check against, every element holds its set's label
+type labels []int
+
+func (l labels) union(a, b int) {
+	from, to := l[b], l[a]
+	for i := range l {
+		if l[i] == from {
+			l[i] = to
+		}
+	}
+}
+
+func (l labels) check(t *testing.T, count int, connected func(a, b int) bool, size func(int) int) {
+	t.Helper()
+	distinct := make(map[int]int)
+	for _, label := range l {
+		distinct[label]++
+	}
+	if count != len(distinct) {
+		t.Fatalf("count %d, expected %d", count, len(distinct))
+	}
+	for a := range l {
+		if size(a) != distinct[l[a]] {
+			t.Fatalf("size of %d is %d, expected %d", a, size(a), distinct[l[a]])
+		}
+		for b := range l {
+			if connected(a, b) != (l[a] == l[b]) {
+				t.Fatalf("connected(%d, %d) is %t", a, b, !(l[a] == l[b]))
+			}
+		}
+	}
+}
+
+func newLabels(n int) labels {
+	l := make(labels, n)
+	for i := range l {
+		l[i] = i
+	}
+	return l
+}
+
+func TestUnionFind(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	u := New(30)
+	expected := newLabels(30)
+	for step := 0; step < 60; step++ {
+		if step%20 == 0 {
+			u.Add()
+			expected = append(expected, len(expected))
+		}
+		a, b := r.Intn(u.Len()), r.Intn(u.Len())
+		if u.Union(a, b) == (expected[a] == expected[b]) {
+			t.Fatalf("union of %d and %d gave the wrong result", a, b)
+		}
+		expected.union(a, b)
+		expected.check(t, u.Count(), u.Connected, u.Size)
+	}
+}
+
+func TestRollback(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	u := NewRollback(30)
+	// the expected labels at each snapshot
+	snapshots := []int{u.Snapshot()}
+	states := []labels{newLabels(30)}
+	for step := 0; step < 200; step++ {
+		current := append(labels(nil), states[len(states)-1]...)
+		if len(states) > 1 && r.Intn(3) == 0 {
+			back := r.Intn(len(states) - 1)
+			u.RollbackTo(snapshots[back])
+			snapshots, states = snapshots[:back+1], states[:back+1]
+			current = append(labels(nil), states[back]...)
+		} else {
+			for i := r.Intn(4); i >= 0; i-- {
+				a, b := r.Intn(30), r.Intn(30)
+				u.Union(a, b)
+				current.union(a, b)
+			}
+			snapshots = append(snapshots, u.Snapshot())
+			states = append(states, current)
+		}
+		current.check(t, u.Count(), u.Connected, u.Size)
+	}
+}